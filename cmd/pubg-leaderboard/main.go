@@ -1,32 +1,63 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/gbasileGP/pubg-leaderboard/internal/api"
 	"github.com/gbasileGP/pubg-leaderboard/internal/client"
 	"github.com/gbasileGP/pubg-leaderboard/internal/config"
+	"github.com/gbasileGP/pubg-leaderboard/internal/lifecycle"
+	"github.com/gbasileGP/pubg-leaderboard/internal/logging"
 	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/tracing"
 	"github.com/gbasileGP/pubg-leaderboard/service"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
-	// Configure the logger
+	// "config print" writes the effective configuration, secrets redacted, instead of starting the service
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		if err := config.Print(os.Stdout, os.Args[3:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Log as JSON at info level until the configuration says otherwise
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 	logger.SetLevel(logrus.InfoLevel)
 
-	// Load configuration
-	cfg, err := config.LoadConfig()
+	// Load configuration from the config file, environment variables and flags
+	cfg, err := config.LoadConfig(os.Args[1:])
 	if err != nil {
 		logger.Fatalf("Error loading config: %v", err)
 	}
 
+	// Configure the logger, whose level and format can be changed at runtime through /admin/logging
+	if err := logging.SetLevel(logger, cfg.LogLevel); err != nil {
+		logger.Fatalf("Error configuring logger: %v", err)
+	}
+	if err := logging.SetFormat(logger, cfg.LogFormat); err != nil {
+		logger.Fatalf("Error configuring logger: %v", err)
+	}
+
 	logger.Info("Starting PUBG Leaderboard service")
 
+	// Trace requests through Redis, MinIO and the PUBG API, tagging logs written within a span with its IDs
+	tracerProvider, err := tracing.NewProvider(context.Background(), cfg.TracingExporter, cfg.TracingSampleRatio)
+	if err != nil {
+		logger.Fatalf("Error initializing tracing: %v", err)
+	}
+	logger.AddHook(tracing.LogHook{})
+
 	logger.Infof("Redis Cluster Service: %s", cfg.RedisAddr)
 
 	// Extract the hostname without the port
@@ -55,16 +86,45 @@ func main() {
 
 	// Initialize the service layer with Redis client and Resty client
 	restyClient := client.NewPUBGClient(cfg, logger) // Assuming you have a Resty client setup for PUBG API
-	leaderboardService := service.NewLeaderboardService(redisClient, restyClient, minioClient, logger)
+	leaderboardService := service.NewLeaderboardService(cfg, redisClient, restyClient, minioClient, logger)
+
+	// Register the operator-defined ranking formulas
+	for name, weights := range cfg.RankingFormulas {
+		if err := leaderboardService.RegisterRankingFormula(name, weights); err != nil {
+			logger.Fatalf("Error registering ranking formula: %v", err)
+		}
+	}
+
+	// Deliver leaderboard events to the registered webhooks
+	webhookService := service.NewWebhookService(cfg, redisClient, logger)
+	leaderboardService.OnEvent(webhookService.HandleEvent)
+
+	// Announce leaderboard updates in the configured Discord and Slack channels
+	notifier, err := service.NewNotifier(cfg, leaderboardService, logger)
+	if err != nil {
+		logger.Fatalf("Error initializing notifier: %v", err)
+	}
+	leaderboardService.OnEvent(notifier.HandleEvent)
 
 	// Initialize the server with the Redis client and logger
-	server := api.NewServer(redisClient, leaderboardService, logger)
+	server, err := api.NewServer(cfg, redisClient, leaderboardService, webhookService, logger)
 	if err != nil {
 		logger.Fatalf("Error initializing server: %v", err)
 	}
 
-	// Start the server
-	if err := server.Run(":8080"); err != nil {
-		logger.Fatalf("Error running server: %v", err)
+	// Stop on SIGTERM or Ctrl-C, draining the server before the background work it depends on
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	manager := lifecycle.NewManager(logger, cfg.ShutdownTimeout)
+	manager.Add("tracing", tracerProvider)
+	manager.Add("webhooks", webhookService)
+	manager.Add("notifier", notifier)
+	manager.Add("leaderboard", leaderboardService)
+	manager.Add("server", server)
+
+	if err := manager.Run(ctx); err != nil {
+		logger.Fatalf("Error running service: %v", err)
 	}
+	logger.Info("Stopped PUBG Leaderboard service")
 }
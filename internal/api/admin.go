@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// handleListLeases is a handler for the lease state of the scheduled jobs, showing which replica runs each.
+func (s *Server) handleListLeases(c *gin.Context) {
+	leases, err := s.leaderboardService.GetLeases(c.Request.Context())
+	if err != nil {
+		s.log(c).WithError(err).Error("Failed to get leases")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leases"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"replica":       s.leaderboardService.ReplicaID(),
+		"leasesEnabled": s.leaderboardService.LeasesEnabled(),
+		"leases":        leases,
+	})
+}
+
+// loggingSettings are the logger settings that can be changed at runtime. Fields left empty in an update are kept.
+type loggingSettings struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// currentLoggingSettings returns the level and format the logger currently uses.
+func (s *Server) currentLoggingSettings() loggingSettings {
+	s.logFormatMu.Lock()
+	defer s.logFormatMu.Unlock()
+	return loggingSettings{Level: s.logger.GetLevel().String(), Format: s.logFormat}
+}
+
+// handleGetLogging is a handler for the current level and format of the logs.
+func (s *Server) handleGetLogging(c *gin.Context) {
+	c.JSON(http.StatusOK, s.currentLoggingSettings())
+}
+
+// handleUpdateLogging is a handler changing the level or format of the logs without a restart, until the next one.
+func (s *Server) handleUpdateLogging(c *gin.Context) {
+	var input loggingSettings
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate both settings before applying either.
+	if input.Level != "" {
+		if _, err := logrus.ParseLevel(input.Level); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if input.Format != "" && input.Format != logging.FormatJSON && input.Format != logging.FormatText {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or text"})
+		return
+	}
+
+	previous := s.currentLoggingSettings()
+	s.logFormatMu.Lock()
+	if input.Level != "" {
+		_ = logging.SetLevel(s.logger, input.Level)
+	}
+	if input.Format != "" {
+		_ = logging.SetFormat(s.logger, input.Format)
+		s.logFormat = input.Format
+	}
+	s.logFormatMu.Unlock()
+
+	current := s.currentLoggingSettings()
+	s.log(c).WithFields(logrus.Fields{
+		"previousLevel":  previous.Level,
+		"previousFormat": previous.Format,
+		"level":          current.Level,
+		"format":         current.Format,
+	}).Warn("api: handleUpdateLogging - Changed logging settings")
+	c.JSON(http.StatusOK, current)
+}
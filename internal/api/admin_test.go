@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestAdminRequiresToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		adminToken    string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "disabled without a configured token", authorization: "Bearer ", wantStatus: http.StatusForbidden},
+		{name: "missing token", adminToken: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", adminToken: "s3cret", authorization: "Bearer s3cre", wantStatus: http.StatusUnauthorized},
+		{name: "token without the bearer scheme", adminToken: "s3cret", authorization: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", adminToken: "s3cret", authorization: "Bearer s3cret", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, testutil.NewFakePUBG(t), "ADMIN_TOKEN="+tt.adminToken, "OPENAPI_VALIDATE_REQUESTS=true")
+
+			for _, request := range []struct{ method, path, body string }{
+				{http.MethodGet, "/admin/leases", ""},
+				{http.MethodGet, "/admin/logging", ""},
+				{http.MethodPut, "/admin/logging", `{"level":"info"}`},
+			} {
+				req, err := http.NewRequest(request.method, ts.URL+request.path, strings.NewReader(request.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Content-Type", "application/json")
+				if tt.authorization != "" {
+					req.Header.Set("Authorization", tt.authorization)
+				}
+
+				resp, err := ts.Client().Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("%s %s: got status %d, want %d", request.method, request.path, resp.StatusCode, tt.wantStatus)
+				}
+				if challenge := resp.Header.Get("WWW-Authenticate"); (resp.StatusCode == http.StatusUnauthorized) != (challenge != "") {
+					t.Errorf("%s %s: got status %d with WWW-Authenticate %q", request.method, request.path, resp.StatusCode, challenge)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+	"go.uber.org/goleak"
+)
+
+// pubgRoutes are requests answered with data that may have to be fetched from the PUBG API.
+var pubgRoutes = []string{
+	"/current-leaderboard",
+	"/leaderboards/squad-fpp",
+	"/leaderboards/squad-fpp/summary",
+	"/rankings/fragger",
+	"/players/compare?ids=a,b",
+	"/watchlists/{id}/leaderboard",
+}
+
+// createWatchlist creates a watchlist of players through the API and returns its ID.
+func createWatchlist(t *testing.T, ts *testServer, playerIDs ...string) string {
+	t.Helper()
+
+	var watchlist model.Watchlist
+	if status := ts.do(t, http.MethodPost, "/watchlists", map[string]interface{}{"name": "friends", "playerIds": playerIDs}, &watchlist); status != http.StatusCreated {
+		t.Fatalf("creating watchlist: got status %d", status)
+	}
+	return watchlist.ID
+}
+
+func TestCircuitOpenIsUnavailable(t *testing.T) {
+	for _, route := range pubgRoutes {
+		t.Run(route, func(t *testing.T) {
+			pubg := testutil.NewFakePUBG(t)
+			pubg.SetStatus(http.StatusInternalServerError)
+			ts := newTestServer(t, pubg,
+				"GAME_MODES=squad-fpp",
+				"RANKING_FORMULAS=fragger=kda:1",
+				"PUBG_BREAKER_FAILURE_THRESHOLD=1",
+				"PUBG_BREAKER_OPEN_TIMEOUT=1m",
+			)
+			route = strings.Replace(route, "{id}", createWatchlist(t, ts, "a"), 1)
+
+			// The first failure opens the circuit breaker, after which the PUBG API is not called.
+			ts.do(t, http.MethodGet, "/current-leaderboard", nil, nil)
+			requests := pubg.Requests()
+
+			resp, err := ts.Client().Get(ts.URL + route)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+			}
+			if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 60 {
+				t.Errorf("got Retry-After %q, want the seconds until the breaker half-opens", resp.Header.Get("Retry-After"))
+			}
+			if pubg.Requests() != requests {
+				t.Error("the PUBG API was called with the circuit breaker open")
+			}
+		})
+	}
+}
+
+func TestSlowPUBGDoesNotHoldRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings []string
+		status   int
+	}{
+		{
+			name:     "request timeout",
+			settings: []string{"REQUEST_TIMEOUT=200ms", "PUBG_REQUEST_TIMEOUT=1m", "FETCH_WAIT_TIMEOUT=1m"},
+			status:   http.StatusGatewayTimeout,
+		},
+		{
+			name:     "PUBG request timeout",
+			settings: []string{"REQUEST_TIMEOUT=1m", "PUBG_REQUEST_TIMEOUT=200ms", "FETCH_WAIT_TIMEOUT=1m"},
+			status:   http.StatusGatewayTimeout,
+		},
+		{
+			// The fetch carries on after the request gave up, until the PUBG API times out.
+			name:     "fetch wait timeout",
+			settings: []string{"REQUEST_TIMEOUT=1m", "PUBG_REQUEST_TIMEOUT=500ms", "FETCH_WAIT_TIMEOUT=200ms"},
+			status:   http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, route := range pubgRoutes {
+			t.Run(tt.name+route, func(t *testing.T) {
+				pubg := testutil.NewFakePUBG(t)
+				pubg.SetHang(true)
+				ts := newTestServer(t, pubg, append([]string{"GAME_MODES=squad-fpp", "RANKING_FORMULAS=fragger=kda:1"}, tt.settings...)...)
+				route := strings.Replace(route, "{id}", createWatchlist(t, ts, "a"), 1)
+
+				start := time.Now()
+				var body map[string]interface{}
+				status := ts.do(t, http.MethodGet, route, nil, &body)
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Errorf("answered after %v, want about 200ms", elapsed)
+				}
+				if status != tt.status {
+					t.Errorf("got status %d %v, want %d", status, body, tt.status)
+				}
+			})
+		}
+	}
+}
+
+func TestClientDisconnectCancelsPUBGCall(t *testing.T) {
+	ignore := goleak.IgnoreCurrent()
+	t.Cleanup(func() { goleak.VerifyNone(t, ignore) })
+
+	pubg := testutil.NewFakePUBG(t)
+	pubg.SetHang(true)
+	ts := newTestServer(t, pubg, "REQUEST_TIMEOUT=1m", "PUBG_REQUEST_TIMEOUT=1m", "FETCH_WAIT_TIMEOUT=1m")
+	t.Cleanup(ts.Client().CloseIdleConnections)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/current-leaderboard", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		resp, err := ts.Client().Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pubg.Requests() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the PUBG API was never called")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want the request to be cancelled", err)
+	}
+
+	select {
+	case <-pubg.Cancelled():
+	case <-time.After(time.Second):
+		t.Error("the PUBG API call carried on after the client went away")
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// liveHeartbeatInterval keeps idle live connections from being closed by proxies.
+	liveHeartbeatInterval = 30 * time.Second
+	// liveWriteTimeout bounds how long a write to a WebSocket client may block.
+	liveWriteTimeout = 10 * time.Second
+	// liveResyncReason explains the resync event ending the stream of a client that fell behind.
+	liveResyncReason = "missed leaderboard changes, reload the leaderboard and reconnect"
+)
+
+// liveUpgrader upgrades live update requests to WebSocket connections. The stream only carries public
+// leaderboard data, so connections from any origin are accepted.
+var liveUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// parseLiveScope reads the scope of a live subscription from the gameMode, shard and players query parameters.
+func parseLiveScope(c *gin.Context) service.LiveScope {
+	return service.LiveScope{
+		GameMode:  c.Query("gameMode"),
+		ShardID:   c.Query("shard"),
+		PlayerIDs: splitList(c.Query("players")),
+	}
+}
+
+// handleLiveSSE streams leaderboard diffs to the client as Server-Sent Events.
+func (s *Server) handleLiveSSE(c *gin.Context) {
+	scope := parseLiveScope(c)
+	updates, unsubscribe := s.leaderboardService.SubscribeLive(scope)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("subscribed", scope)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case diff, ok := <-updates:
+			if !ok {
+				// The client fell behind and missed diffs: it has to reload the leaderboard.
+				c.SSEvent("resync", gin.H{"reason": liveResyncReason})
+				return false
+			}
+			c.SSEvent("diff", diff)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"time": time.Now().UTC()})
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		case <-s.closing:
+			return false
+		}
+	})
+}
+
+// handleLiveWebSocket streams leaderboard diffs to the client over a WebSocket as JSON messages.
+func (s *Server) handleLiveWebSocket(c *gin.Context) {
+	conn, err := liveUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied with an error status.
+		s.log(c).WithError(err).Warn("api: handleLiveWebSocket - Failed to upgrade connection")
+		return
+	}
+	defer conn.Close()
+
+	updates, unsubscribe := s.leaderboardService.SubscribeLive(parseLiveScope(c))
+	defer unsubscribe()
+
+	// Messages from the client are not used, but reading is required to notice when it goes away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case diff, ok := <-updates:
+			_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if !ok {
+				// The client fell behind and missed diffs: it has to reload the leaderboard.
+				_ = conn.WriteJSON(gin.H{"event": "resync", "data": gin.H{"reason": liveResyncReason}})
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, liveResyncReason), time.Now().Add(liveWriteTimeout))
+				return
+			}
+			if err := conn.WriteJSON(gin.H{"event": "diff", "data": diff}); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-s.closing:
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(liveWriteTimeout))
+			return
+		}
+	}
+}
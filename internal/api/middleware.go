@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/logging"
+	"github.com/gbasileGP/pubg-leaderboard/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// requestIDHeader carries the ID of a request, taken from the client or a proxy in front of the API when valid
+// and generated otherwise, and echoed in the response.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// requestID assigns every request an ID, kept from the X-Request-ID header when valid, and passes a logger
+// tagged with it to the handlers and the services through the request context.
+func (s *Server) requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Header(requestIDHeader, id)
+
+		logger := s.logger.WithField("request_id", id)
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+		c.Next()
+	}
+}
+
+// validRequestID reports whether a request ID from a client is short and printable, so it can be logged as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// logRequests logs every request once served, with its route, status and latency, through the request-scoped
+// logger. Server errors are logged as errors and client errors as warnings.
+func (s *Server) logRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		entry := s.log(c).WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"route":      c.FullPath(),
+			"path":       c.Request.URL.Path,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":      c.Writer.Size(),
+			"client_ip":  c.ClientIP(),
+			"user_agent": c.Request.UserAgent(),
+		})
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request served")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request served")
+		default:
+			entry.Info("Request served")
+		}
+	}
+}
+
+// recoverPanics answers 500 to requests whose handler panicked, logging the panic and its stack trace through the
+// request-scoped logger instead of gin's text output.
+func (s *Server) recoverPanics() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		s.log(c).WithField("panic", recovered).WithField("stack", string(debug.Stack())).Error("Request handler panicked")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	})
+}
+
+// log returns the logger of a request, tagged with its request ID.
+func (s *Server) log(c *gin.Context) *logrus.Entry {
+	return logging.FromContext(c.Request.Context(), s.logger)
+}
+
+// notProbe reports whether a request is not a liveness or readiness probe, which are frequent enough to drown
+// the traces of actual requests.
+func notProbe(r *http.Request) bool {
+	return r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+}
+
+// observeRequests counts API requests and observes their latency in the metrics, by route template so paths
+// with IDs do not create a series each. Requests matching no route are counted under "unmatched".
+func observeRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// requestTimeout bounds the context of every request by timeout, except the routes in exempt such as long-lived
+// streams. The handlers stop waiting on Redis, MinIO and the PUBG API once it expires. A timeout of 0 disables it.
+func requestTimeout(timeout time.Duration, exempt ...string) gin.HandlerFunc {
+	exempted := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		exempted[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if timeout <= 0 || exempted[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// respondContextError answers a request whose context ended before its data was retrieved: 504 when the request
+// timed out, nothing when the client went away. It returns false for other errors.
+func (s *Server) respondContextError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		s.log(c).WithError(err).WithField("path", c.FullPath()).Warn("Request timed out")
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+		return true
+	case errors.Is(err, context.Canceled) && c.Request.Context().Err() != nil:
+		s.log(c).WithField("path", c.FullPath()).Info("Client went away before the response")
+		c.Abort()
+		return true
+	}
+	return false
+}
+
+// requireAdminToken rejects requests without the bearer token, compared in constant time, with 401. Without a
+// token configured every request is refused with 403, so the admin endpoints are never left open.
+func requireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled, set ADMIN_TOKEN to enable them"})
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// openAPISpec is the OpenAPI document describing every route registered in setupRoutes.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// swaggerUI holds Swagger UI 5.18.2, under its Apache 2.0 license, and the script starting it, so the docs page
+// works offline and under a Content-Security-Policy restricted to 'self'.
+//
+//go:embed swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css swagger-ui/swagger-initializer.js
+var swaggerUI embed.FS
+
+// docsPage renders the OpenAPI document with the embedded Swagger UI.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>PUBG Leaderboard API</title>
+  <link rel="stylesheet" href="/docs/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/swagger-ui-bundle.js"></script>
+  <script src="/docs/swagger-initializer.js"></script>
+</body>
+</html>`
+
+// loadOpenAPISpec parses and validates the embedded OpenAPI document.
+func loadOpenAPISpec() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("api - error loading OpenAPI spec: %v", err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("api - invalid OpenAPI spec: %v", err)
+	}
+
+	return doc, nil
+}
+
+// openAPIPath converts a gin route path such as /player-stats/:playerID into its OpenAPI form /player-stats/{playerID}.
+func openAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// checkSpecRoutes logs every registered route missing from the spec and every documented operation without a route,
+// so the document and the handlers cannot silently drift apart.
+func (s *Server) checkSpecRoutes() {
+	registered := make(map[string]bool)
+	for _, route := range s.router.Routes() {
+		path := openAPIPath(route.Path)
+		registered[route.Method+" "+path] = true
+
+		pathItem := s.openAPI.Paths.Value(path)
+		if pathItem == nil || pathItem.GetOperation(route.Method) == nil {
+			s.logger.WithFields(logrus.Fields{
+				"method": route.Method,
+				"path":   route.Path,
+			}).Warn("api: checkSpecRoutes - Route is not documented in the OpenAPI spec")
+		}
+	}
+
+	for path, pathItem := range s.openAPI.Paths.Map() {
+		for method := range pathItem.Operations() {
+			if !registered[method+" "+path] {
+				s.logger.WithFields(logrus.Fields{
+					"method": method,
+					"path":   path,
+				}).Warn("api: checkSpecRoutes - Documented operation has no registered route")
+			}
+		}
+	}
+}
+
+// handleGetOpenAPISpec serves the OpenAPI document as JSON.
+func (s *Server) handleGetOpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, s.openAPI)
+}
+
+// handleGetDocs serves the interactive documentation page.
+func (s *Server) handleGetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+// handleGetDocsAsset serves the Swagger UI files loaded by the docs page.
+func (s *Server) handleGetDocsAsset(c *gin.Context) {
+	asset := c.Param("asset")
+	data, err := swaggerUI.ReadFile("swagger-ui/" + asset)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "asset not found"})
+		return
+	}
+
+	contentType := "text/css; charset=utf-8"
+	if path.Ext(asset) == ".js" {
+		contentType = "text/javascript; charset=utf-8"
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// bufferedResponseWriter holds the response body back until it has been validated against the spec.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(data string) (int, error) {
+	return w.body.WriteString(data)
+}
+
+// openAPIValidator returns middleware validating requests and, optionally, responses against the OpenAPI spec.
+// Invalid requests are rejected with 400. Invalid responses are replaced with a 500 describing the mismatch,
+// which is meant for test runs rather than production traffic.
+func (s *Server) openAPIValidator(validateRequests, validateResponses bool) gin.HandlerFunc {
+	// The admin token is checked by requireAdminToken, which also answers requests without one.
+	options := &openapi3filter.Options{IncludeResponseStatus: true, AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+	// Non-JSON responses such as the docs page are checked for status and content type only.
+	nonJSONOptions := &openapi3filter.Options{IncludeResponseStatus: true, ExcludeResponseBody: true}
+
+	return func(c *gin.Context) {
+		route := s.findOpenAPIRoute(c)
+		if route == nil {
+			c.Next()
+			return
+		}
+
+		pathParams := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			pathParams[param.Key] = param.Value
+		}
+
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+
+		if validateRequests {
+			if err := openapi3filter.ValidateRequest(c.Request.Context(), requestInput); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// Streaming responses never complete, so they cannot be buffered for validation.
+		if !validateResponses || route.Operation.Extensions["x-streaming"] == true {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		responseOptions := options
+		if !strings.Contains(writer.Header().Get("Content-Type"), "json") {
+			responseOptions = nonJSONOptions
+		}
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 writer.Status(),
+			Header:                 writer.Header(),
+			Options:                responseOptions,
+		}
+		responseInput.SetBodyBytes(writer.body.Bytes())
+
+		if err := openapi3filter.ValidateResponse(c.Request.Context(), responseInput); err != nil {
+			s.log(c).WithError(err).WithField("path", c.FullPath()).Error("api: openAPIValidator - Response does not match the OpenAPI spec")
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+			_, _ = c.Writer.WriteString(fmt.Sprintf(`{"error":%q}`, "response does not match the OpenAPI spec: "+err.Error()))
+			return
+		}
+
+		_, _ = c.Writer.Write(writer.body.Bytes())
+	}
+}
+
+// findOpenAPIRoute resolves the spec operation for the gin route matched by the request, if it is documented.
+func (s *Server) findOpenAPIRoute(c *gin.Context) *routers.Route {
+	if c.FullPath() == "" {
+		return nil
+	}
+
+	path := openAPIPath(c.FullPath())
+	pathItem := s.openAPI.Paths.Value(path)
+	if pathItem == nil {
+		return nil
+	}
+
+	operation := pathItem.GetOperation(c.Request.Method)
+	if operation == nil {
+		return nil
+	}
+
+	return &routers.Route{
+		Spec:      s.openAPI,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    c.Request.Method,
+		Operation: operation,
+	}
+}
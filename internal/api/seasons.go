@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gbasileGP/pubg-leaderboard/service"
+	"github.com/gin-gonic/gin"
+)
+
+// handleListSeasons is a handler for listing every season of the shard.
+func (s *Server) handleListSeasons(c *gin.Context) {
+	seasons, err := s.leaderboardService.GetSeasons(c.Request.Context())
+	if s.respondPUBGError(c, err) {
+		return
+	} else if err != nil {
+		s.log(c).WithError(err).Error("Failed to get seasons")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get seasons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seasons": seasons})
+}
+
+// handleGetSeasonLeaderboard is a handler for the leaderboard of a game mode in any season, with the same
+// filters and sorting as the current leaderboards.
+func (s *Server) handleGetSeasonLeaderboard(c *gin.Context) {
+	seasonID := c.Param("seasonID")
+	gameMode := c.Param("gameMode")
+
+	query, err := parseLeaderboardQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leaderboardData, err := s.leaderboardService.GetSeasonLeaderboard(c.Request.Context(), seasonID, c.Query("shard"), gameMode)
+	if err != nil {
+		switch {
+		case s.respondPUBGError(c, err):
+		case errors.Is(err, service.ErrUnknownGameMode):
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Game mode %q is not served", gameMode)})
+		case errors.Is(err, service.ErrUnknownSeason):
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Season %q not found", seasonID)})
+		case errors.Is(err, service.ErrSeasonLeaderboardNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("No leaderboard for season %q", seasonID)})
+		default:
+			s.log(c).WithError(err).Error("Failed to get season leaderboard")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get season leaderboard"})
+		}
+		return
+	}
+
+	leaderboardData, err = service.ApplyLeaderboardQuery(leaderboardData, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setDataAgeHeaders(c, leaderboardData)
+	c.JSON(http.StatusOK, leaderboardData)
+}
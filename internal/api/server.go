@@ -2,14 +2,27 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/gbasileGP/pubg-leaderboard/internal/client"
+	"github.com/gbasileGP/pubg-leaderboard/internal/config"
+	"github.com/gbasileGP/pubg-leaderboard/internal/logging"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
 	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/tracing"
 	"github.com/gbasileGP/pubg-leaderboard/service"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // Server represents the server configuration with a router, a Redis client, a logger, and the leaderboard service.
@@ -17,45 +30,145 @@ type Server struct {
 	router             *gin.Engine
 	redisClient        *store.RedisClient
 	leaderboardService *service.LeaderboardService
+	webhookService     *service.WebhookService
 	logger             *logrus.Logger
+	openAPI            *openapi3.T
+
+	backupsBucket string // MinIO bucket the backups are written to and restored from
+	adminToken    string // Bearer token of the /admin endpoints, disabled when empty
+
+	logFormatMu sync.Mutex // Guards logFormat and changes of the logger settings
+	logFormat   string     // Format the logger writes, changed through /admin/logging
+
+	addr       string        // Address the HTTP server listens on
+	httpServer *http.Server  // Set by Start
+	closing    chan struct{} // Closed by Stop so long-lived streams return before the server shuts down
+	failed     chan error    // Receives the error of a server that stopped serving on its own
 }
 
-// NewServer initializes a new server with configured Redis client, leaderboard service, and logger passed from main.
-func NewServer(redisClient *store.RedisClient, leaderboardService *service.LeaderboardService, logger *logrus.Logger) *Server {
-	router := gin.Default()
+// NewServer initializes a new server with configured Redis client, leaderboard and webhook services, and logger passed from main.
+func NewServer(cfg *config.Config, redisClient *store.RedisClient, leaderboardService *service.LeaderboardService, webhookService *service.WebhookService, logger *logrus.Logger) (*Server, error) {
+	openAPI, err := loadOpenAPISpec()
+	if err != nil {
+		return nil, err
+	}
+
+	router := gin.New()
 
 	server := &Server{
 		router:             router,
 		redisClient:        redisClient,
 		leaderboardService: leaderboardService,
+		webhookService:     webhookService,
 		logger:             logger,
+		openAPI:            openAPI,
+		addr:               ":" + cfg.AppPort,
+		closing:            make(chan struct{}),
+		failed:             make(chan error, 1),
+		logFormat:          cfg.LogFormat,
+		backupsBucket:      cfg.BackupsBucket,
+		adminToken:         cfg.AdminToken,
+	}
+	if server.logFormat == "" {
+		server.logFormat = logging.FormatJSON
+	}
+
+	// Requests are logged through logrus with their request ID, which the handlers and services log with too.
+	router.Use(
+		otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(notProbe)),
+		server.requestID(),
+		server.logRequests(),
+		server.recoverPanics(),
+		observeRequests(),
+		requestTimeout(cfg.RequestTimeout, "/live/sse", "/live/ws"),
+	)
+
+	// Responses are always validated in gin's test mode so handler changes that break the contract fail loudly.
+	validateResponses := cfg.OpenAPIValidateResponses || gin.Mode() == gin.TestMode
+	if cfg.OpenAPIValidateRequests || validateResponses {
+		router.Use(server.openAPIValidator(cfg.OpenAPIValidateRequests, validateResponses))
 	}
+
 	server.setupRoutes()
+	server.checkSpecRoutes()
 
-	return server
+	return server, nil
 }
 
 // setupRoutes defines all the routes for the server.
 func (s *Server) setupRoutes() {
 	s.router.GET("/ping", s.handlePing)
+	s.router.GET("/healthz", s.handleHealthz)
+	s.router.GET("/readyz", s.handleReadyz)
+	s.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	s.router.GET("/redis-ping", s.handleRedisPing)
 	s.router.GET("/current-season", s.handleGetCurrentSeason)
 	s.router.GET("/current-leaderboard", s.handleGetCurrentLeaderboard)
+	s.router.GET("/leaderboards/:gameMode", s.handleGetLeaderboard)
+	s.router.GET("/leaderboards/:gameMode/summary", s.handleGetLeaderboardSummary)
+	s.router.GET("/leaderboards/:gameMode/summary/history", s.handleGetSummaryHistory)
+	s.router.GET("/seasons", s.handleListSeasons)
+	s.router.GET("/seasons/:seasonID/leaderboards/:gameMode", s.handleGetSeasonLeaderboard)
 	s.router.GET("/player-stats/:playerID", s.handleGetPlayerStats)
+	s.router.GET("/players/compare", s.handleComparePlayers)
+	s.router.GET("/rankings", s.handleListRankings)
+	s.router.GET("/rankings/:formula", s.handleGetRanking)
 	s.router.POST("/backup-leaderboard", s.handleBackupLeaderboard)
 	s.router.POST("/restore-leaderboard", s.handleRestoreLeaderboard)
+	s.router.GET("/watchlists", s.handleListWatchlists)
+	s.router.POST("/watchlists", s.handleCreateWatchlist)
+	s.router.GET("/watchlists/:id", s.handleGetWatchlist)
+	s.router.PUT("/watchlists/:id", s.handleUpdateWatchlist)
+	s.router.DELETE("/watchlists/:id", s.handleDeleteWatchlist)
+	s.router.GET("/watchlists/:id/leaderboard", s.handleGetWatchlistLeaderboard)
+	s.router.GET("/webhooks", s.handleListWebhooks)
+	s.router.POST("/webhooks", s.handleCreateWebhook)
+	s.router.GET("/webhooks/:id", s.handleGetWebhook)
+	s.router.PUT("/webhooks/:id", s.handleUpdateWebhook)
+	s.router.DELETE("/webhooks/:id", s.handleDeleteWebhook)
+	s.router.POST("/webhooks/:id/test", s.handleTestWebhook)
+	s.router.GET("/webhooks/:id/deliveries", s.handleListWebhookDeliveries)
+	s.router.GET("/live/sse", s.handleLiveSSE)
+	s.router.GET("/live/ws", s.handleLiveWebSocket)
+	admin := s.router.Group("/admin", requireAdminToken(s.adminToken))
+	admin.GET("/leases", s.handleListLeases)
+	admin.GET("/logging", s.handleGetLogging)
+	admin.PUT("/logging", s.handleUpdateLogging)
+	s.router.GET("/openapi.json", s.handleGetOpenAPISpec)
+	s.router.GET("/docs", s.handleGetDocs)
+	s.router.GET("/docs/:asset", s.handleGetDocsAsset)
 }
 
-// handlePing is a handler for the API health check route.
+// handlePing is a handler for the API health check route. It reports the circuit breaker guarding the PUBG API.
 func (s *Server) handlePing(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "pong",
+		"pubgApi": gin.H{"circuitBreaker": s.leaderboardService.PUBGBreakerStatus()},
+	})
+}
+
+// handleHealthz is a handler for the liveness probe. It answers as long as the server serves requests and checks
+// no dependency, so an outage of Redis, MinIO or the PUBG API does not get the replica restarted.
+func (s *Server) handleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleReadyz is a handler for the readiness probe. It reports the status of every dependency and answers 503
+// while a critical one is down, so the replica receives no traffic until it can serve it.
+func (s *Server) handleReadyz(c *gin.Context) {
+	readiness := s.leaderboardService.CheckReadiness(c.Request.Context())
+	if !readiness.Ready {
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+	c.JSON(http.StatusOK, readiness)
 }
 
 // handleRedisPing is a handler for the Redis health check route.
 func (s *Server) handleRedisPing(c *gin.Context) {
 	err := s.redisClient.Ping(c.Request.Context())
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to ping Redis")
+		s.log(c).WithError(err).Error("Failed to ping Redis")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ping Redis"})
 		return
 	}
@@ -64,38 +177,201 @@ func (s *Server) handleRedisPing(c *gin.Context) {
 
 // handleGetCurrentSeason is a handler for fetching the current PUBG season.
 func (s *Server) handleGetCurrentSeason(c *gin.Context) {
-	seasonData, err := s.leaderboardService.GetCurrentSeason(context.Background())
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to get current season")
+	seasonData, err := s.leaderboardService.GetCurrentSeason(c.Request.Context())
+	if s.respondPUBGError(c, err) {
+		return
+	} else if err != nil {
+		s.log(c).WithError(err).Error("Failed to get current season")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current season"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"seasonData": seasonData})
 }
 
-// handleGetCurrentLeaderboard is a handler for fetching the current leaderboard.
+// respondPUBGError answers a request whose data could not be retrieved from the PUBG API in time: 503 with
+// Retry-After when the fetch is still in progress or the circuit breaker is open, and as respondContextError when
+// the request's context ended. It returns false for other errors.
+func (s *Server) respondPUBGError(c *gin.Context, err error) bool {
+	switch {
+	case s.respondContextError(c, err):
+	case errors.Is(err, service.ErrFetchTimeout):
+		respondFetchTimeout(c)
+	case errors.Is(err, client.ErrCircuitOpen):
+		s.respondCircuitOpen(c)
+	default:
+		return false
+	}
+	return true
+}
+
+// fetchRetryAfter is the Retry-After, in seconds, of requests that gave up waiting for a fetch.
+const fetchRetryAfter = "1"
+
+// respondFetchTimeout tells the client to retry shortly, as the data it asked for is still being fetched.
+func respondFetchTimeout(c *gin.Context) {
+	c.Header("Retry-After", fetchRetryAfter)
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Data is being fetched from the PUBG API, retry shortly"})
+}
+
+// respondCircuitOpen tells the client to retry once the circuit breaker of the PUBG API lets calls through again,
+// as the data it asked for is neither cached nor backed up.
+func (s *Server) respondCircuitOpen(c *gin.Context) {
+	retryAfter := time.Second
+	if status := s.leaderboardService.PUBGBreakerStatus(); status.RetryAt != nil {
+		retryAfter = max(time.Until(*status.RetryAt).Round(time.Second), time.Second)
+	}
+	c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The PUBG API is unavailable and no cached data is available, retry later"})
+}
+
+// handleGetCurrentLeaderboard is a handler for fetching the current leaderboard, optionally filtered and sorted.
 func (s *Server) handleGetCurrentLeaderboard(c *gin.Context) {
-	leaderboardData, err := s.leaderboardService.GetCurrentLeaderboard(context.Background())
+	s.respondLeaderboard(c, s.leaderboardService.DefaultGameMode())
+}
+
+// handleGetLeaderboard is a handler for fetching the leaderboard of a game mode, optionally filtered and sorted.
+func (s *Server) handleGetLeaderboard(c *gin.Context) {
+	s.respondLeaderboard(c, c.Param("gameMode"))
+}
+
+// respondLeaderboard writes the leaderboard of a game mode filtered and sorted by the request's query parameters.
+func (s *Server) respondLeaderboard(c *gin.Context, gameMode string) {
+	query, err := parseLeaderboardQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leaderboardData, err := s.leaderboardService.GetLeaderboard(c.Request.Context(), gameMode)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to get current leaderboard")
+		if s.respondPUBGError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrUnknownGameMode) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Game mode %q is not served", gameMode)})
+			return
+		}
+		s.log(c).WithError(err).Error("Failed to get current leaderboard")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current leaderboard"})
 		return
 	}
 
+	leaderboardData, err = service.ApplyLeaderboardQuery(leaderboardData, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setDataAgeHeaders(c, leaderboardData)
 	// Depending on your LeaderboardData model, you may need to adjust how you marshal the data to JSON
 	c.JSON(http.StatusOK, leaderboardData)
 }
 
+// setDataAgeHeaders reports how long ago a leaderboard was fetched from the PUBG API in X-Data-Age, in seconds,
+// and adds a Warning header when it is served stale.
+func setDataAgeHeaders(c *gin.Context, leaderboard *model.LeaderboardResponse) {
+	if leaderboard.FetchedAt != nil {
+		c.Header("X-Data-Age", strconv.Itoa(int(time.Since(*leaderboard.FetchedAt).Seconds())))
+	}
+	if leaderboard.Stale {
+		c.Header("Warning", `110 - "Response is Stale"`)
+	}
+}
+
+// handleGetLeaderboardSummary is a handler for fetching the aggregate statistics of a game mode's leaderboard.
+func (s *Server) handleGetLeaderboardSummary(c *gin.Context) {
+	gameMode := c.Param("gameMode")
+
+	summary, err := s.leaderboardService.GetLeaderboardSummary(c.Request.Context(), gameMode)
+	if err != nil {
+		if s.respondPUBGError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrUnknownGameMode) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Game mode %q is not served", gameMode)})
+			return
+		}
+		s.log(c).WithError(err).Error("Failed to get leaderboard summary")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leaderboard summary"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// handleGetSummaryHistory is a handler for fetching how a game mode's leaderboard summary moved this season.
+func (s *Server) handleGetSummaryHistory(c *gin.Context) {
+	gameMode := c.Param("gameMode")
+
+	since, until := time.Unix(0, 0), time.Now()
+	for name, target := range map[string]*time.Time{"since": &since, "until": &until} {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("query parameter %s must be an RFC 3339 timestamp", name)})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	points, err := s.leaderboardService.GetSummaryHistory(c.Request.Context(), gameMode, since, until)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownGameMode) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Game mode %q is not served", gameMode)})
+			return
+		}
+		s.log(c).WithError(err).Error("Failed to get leaderboard summary history")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leaderboard summary history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gameMode": gameMode, "points": points})
+}
+
+// parseLeaderboardQuery reads the leaderboard filter and sort query parameters.
+func parseLeaderboardQuery(c *gin.Context) (service.LeaderboardQuery, error) {
+	query := service.LeaderboardQuery{
+		Tier:    c.Query("tier"),
+		SubTier: c.Query("subTier"),
+		Name:    c.Query("name"),
+		SortBy:  c.Query("sort"),
+		Order:   service.SortOrder(c.Query("order")),
+	}
+
+	intParams := map[string]*int{
+		"minGames": &query.MinGames,
+		"minRank":  &query.MinRank,
+		"maxRank":  &query.MaxRank,
+	}
+	for name, target := range intParams {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return query, fmt.Errorf("query parameter %s must be a non-negative integer", name)
+		}
+		*target = parsed
+	}
+
+	return query, query.Validate()
+}
+
 // handleGetPlayerStats is a handler for fetching the stats of a single player.
 func (s *Server) handleGetPlayerStats(c *gin.Context) {
 	playerID := c.Param("playerID")
+	gameMode := c.DefaultQuery("gameMode", s.leaderboardService.DefaultGameMode())
 
-	rank, gamesPlayed, wins, err := s.leaderboardService.GetPlayerStats(context.Background(), playerID)
+	rank, gamesPlayed, wins, err := s.leaderboardService.GetPlayerStats(c.Request.Context(), gameMode, playerID)
 	if err != nil {
-		if err == store.ErrCacheMiss {
+		if s.respondContextError(c, err) {
+			return
+		} else if err == store.ErrCacheMiss || errors.Is(err, service.ErrUnknownGameMode) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Player stats not found"})
 		} else {
-			s.logger.WithError(err).Error("Failed to get player stats")
+			s.log(c).WithError(err).Error("Failed to get player stats")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get player stats"})
 		}
 		return
@@ -110,42 +386,143 @@ func (s *Server) handleGetPlayerStats(c *gin.Context) {
 	})
 }
 
-// handleBackupLeaderboard handles the request to backup the current leaderboard.
-func (s *Server) handleBackupLeaderboard(c *gin.Context) {
-	bucketName := "pubg-leaderboard" // This can be a query param or can be set in the config/env.
-	backupFileName := fmt.Sprintf("leaderboard_backup_%s.json", time.Now().Format("20060102_150405"))
+// handleComparePlayers is a handler for comparing two or more players, given by ID or name, side by side.
+func (s *Server) handleComparePlayers(c *gin.Context) {
+	ids := splitList(c.Query("ids"))
+	if len(ids) < 2 || len(ids) > service.MaxComparedPlayers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ids must list between 2 and %d player IDs or names", service.MaxComparedPlayers)})
+		return
+	}
+
+	gameModes := []string{s.leaderboardService.DefaultGameMode()}
+	if value := c.Query("gameModes"); value == "all" {
+		gameModes = s.leaderboardService.GameModes()
+	} else if value != "" {
+		gameModes = splitList(value)
+	}
+
+	comparison, err := s.leaderboardService.ComparePlayers(c.Request.Context(), ids, gameModes)
+	if err != nil {
+		if s.respondPUBGError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrUnknownGameMode) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		s.log(c).WithError(err).Error("Failed to compare players")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare players"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
 
-	err := s.leaderboardService.BackupLeaderboardData(c.Request.Context(), bucketName, backupFileName)
+// splitList splits a comma-separated query parameter, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// handleListRankings is a handler for listing the registered ranking formulas.
+func (s *Server) handleListRankings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"formulas": s.leaderboardService.RankingFormulas()})
+}
+
+// handleGetRanking is a handler for fetching the current leaderboard ranked by a formula.
+func (s *Server) handleGetRanking(c *gin.Context) {
+	formula := c.Param("formula")
+	gameMode := c.DefaultQuery("gameMode", s.leaderboardService.DefaultGameMode())
+
+	leaderboardData, err := s.leaderboardService.GetLeaderboard(c.Request.Context(), gameMode)
+	if err != nil {
+		if s.respondPUBGError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrUnknownGameMode) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Game mode %q is not served", gameMode)})
+			return
+		}
+		s.log(c).WithError(err).Error("Failed to get current leaderboard")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current leaderboard"})
+		return
+	}
+
+	ranking, ok := s.leaderboardService.RankByFormula(leaderboardData, formula)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Ranking formula %q not found", formula)})
+		return
+	}
+
+	c.JSON(http.StatusOK, ranking)
+}
+
+// handleBackupLeaderboard handles the request to backup the current leaderboard of every game mode.
+func (s *Server) handleBackupLeaderboard(c *gin.Context) {
+	objects, err := s.leaderboardService.BackupLeaderboardData(c.Request.Context())
 	if err != nil {
-		s.logger.WithError(err).Error("API: Failed to backup leaderboard data")
+		s.log(c).WithError(err).WithField("backedUp", objects).Error("API: Failed to backup leaderboard data")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backup leaderboard data"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Leaderboard data backed up successfully", "bucket": bucketName, "file": backupFileName})
+	c.JSON(http.StatusOK, gin.H{"message": "Leaderboard data backed up successfully", "bucket": s.backupsBucket, "files": objects})
 }
 
 // handleRestoreLeaderboard handles the request to restore the leaderboard from a backup.
 func (s *Server) handleRestoreLeaderboard(c *gin.Context) {
-	bucketName := "pubg-leaderboard"  // This can be a query param or can be set in the config/env.
-	backupFileName := c.Query("file") // The name of the backup file to restore from.
+	backupFileName := c.Query("file") // The name of the backup object to restore from.
 
 	if backupFileName == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Backup file name is required"})
 		return
 	}
 
-	err := s.leaderboardService.RestoreLeaderboardData(c.Request.Context(), bucketName, backupFileName)
+	err := s.leaderboardService.RestoreLeaderboardData(c.Request.Context(), backupFileName)
 	if err != nil {
-		s.logger.WithError(err).Error("API: Failed to restore leaderboard data")
+		s.log(c).WithError(err).Error("API: Failed to restore leaderboard data")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore leaderboard data"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Leaderboard data restored successfully", "bucket": bucketName, "file": backupFileName})
+	c.JSON(http.StatusOK, gin.H{"message": "Leaderboard data restored successfully", "bucket": s.backupsBucket, "file": backupFileName})
 }
 
-// Run starts the HTTP server on a specific address.
-func (s *Server) Run(addr string) error {
-	return s.router.Run(addr)
+// Start listens on the configured port and serves requests in the background.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("api - failed to listen on %s: %w", s.addr, err)
+	}
+
+	s.httpServer = &http.Server{Handler: s.router}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.failed <- err
+		}
+		close(s.failed)
+	}()
+
+	s.logger.WithField("addr", listener.Addr().String()).Info("api: Start - Listening")
+	return nil
+}
+
+// Failed reports the error of a server that stopped serving without being stopped.
+func (s *Server) Failed() <-chan error {
+	return s.failed
+}
+
+// Stop ends the live streams, stops accepting connections and waits for in-flight requests to complete,
+// giving up when ctx is done.
+func (s *Server) Stop(ctx context.Context) error {
+	close(s.closing)
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
 }
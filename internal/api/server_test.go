@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/client"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+	"github.com/gbasileGP/pubg-leaderboard/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	// Test mode validates every response against the OpenAPI spec.
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testServer is the API served over HTTP in front of the services, backed by an in-memory Redis and a fake
+// PUBG API.
+type testServer struct {
+	*httptest.Server
+	server             *Server
+	leaderboardService *service.LeaderboardService
+	webhookService     *service.WebhookService
+}
+
+// newTestServer serves the API against pubg, configured with settings on top of the test defaults.
+func newTestServer(t *testing.T, pubg *testutil.FakePUBG, settings ...string) *testServer {
+	t.Helper()
+
+	cfg := testutil.Config(t, pubg.URL, settings...)
+	logger := testutil.Logger()
+	_, redisClient := testutil.NewRedis(t)
+	minioClient, err := store.NewMinioClient(cfg.MinioEndpoint, cfg.MinioAccessKey, cfg.MinioSecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaderboardService := service.NewLeaderboardService(cfg, redisClient, client.NewPUBGClient(cfg, logger), minioClient, logger)
+	webhookService := service.NewWebhookService(cfg, redisClient, logger)
+	leaderboardService.OnEvent(webhookService.HandleEvent)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = webhookService.Stop(ctx)
+	})
+
+	server, err := NewServer(cfg, redisClient, leaderboardService, webhookService, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.router)
+	t.Cleanup(httpServer.Close)
+
+	return &testServer{Server: httpServer, server: server, leaderboardService: leaderboardService, webhookService: webhookService}
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into out, if given, returning the
+// status code.
+func (ts *testServer) do(t *testing.T, method, path string, body, out interface{}) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
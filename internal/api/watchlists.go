@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/service"
+	"github.com/gin-gonic/gin"
+)
+
+// respondWatchlistError maps watchlist service errors to HTTP responses.
+func (s *Server) respondWatchlistError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrCacheMiss):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Watchlist not found"})
+	case errors.Is(err, service.ErrInvalidWatchlist):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		s.log(c).WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// handleListWatchlists is a handler for listing the watchlists.
+func (s *Server) handleListWatchlists(c *gin.Context) {
+	watchlists, err := s.leaderboardService.ListWatchlists(c.Request.Context())
+	if err != nil {
+		s.respondWatchlistError(c, err, "Failed to list watchlists")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watchlists": watchlists})
+}
+
+// handleCreateWatchlist is a handler for creating a watchlist.
+func (s *Server) handleCreateWatchlist(c *gin.Context) {
+	var input service.WatchlistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watchlist, err := s.leaderboardService.CreateWatchlist(c.Request.Context(), input)
+	if err != nil {
+		s.respondWatchlistError(c, err, "Failed to create watchlist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, watchlist)
+}
+
+// handleGetWatchlist is a handler for fetching a watchlist.
+func (s *Server) handleGetWatchlist(c *gin.Context) {
+	watchlist, err := s.leaderboardService.GetWatchlist(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		s.respondWatchlistError(c, err, "Failed to get watchlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, watchlist)
+}
+
+// handleUpdateWatchlist is a handler for replacing a watchlist.
+func (s *Server) handleUpdateWatchlist(c *gin.Context) {
+	var input service.WatchlistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watchlist, err := s.leaderboardService.UpdateWatchlist(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		s.respondWatchlistError(c, err, "Failed to update watchlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, watchlist)
+}
+
+// handleDeleteWatchlist is a handler for removing a watchlist.
+func (s *Server) handleDeleteWatchlist(c *gin.Context) {
+	if err := s.leaderboardService.DeleteWatchlist(c.Request.Context(), c.Param("id")); err != nil {
+		s.respondWatchlistError(c, err, "Failed to delete watchlist")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleGetWatchlistLeaderboard is a handler for the standing of a watchlist's players on a game mode's leaderboard.
+func (s *Server) handleGetWatchlistLeaderboard(c *gin.Context) {
+	gameMode := c.DefaultQuery("gameMode", s.leaderboardService.DefaultGameMode())
+
+	var since *time.Time
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter since must be an RFC 3339 timestamp"})
+			return
+		}
+		since = &parsed
+	}
+
+	leaderboard, err := s.leaderboardService.GetWatchlistLeaderboard(c.Request.Context(), c.Param("id"), gameMode, since)
+	if err != nil {
+		if s.respondPUBGError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrUnknownGameMode) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Game mode %q is not served", gameMode)})
+			return
+		}
+		s.respondWatchlistError(c, err, "Failed to get watchlist leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
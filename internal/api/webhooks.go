@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/service"
+	"github.com/gin-gonic/gin"
+)
+
+// withoutSecret returns a copy of the subscription safe to return from read endpoints.
+func withoutSecret(webhook *model.WebhookSubscription) *model.WebhookSubscription {
+	redacted := *webhook
+	redacted.Secret = ""
+	return &redacted
+}
+
+// respondWebhookError maps webhook service errors to HTTP responses.
+func (s *Server) respondWebhookError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrCacheMiss):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.Is(err, service.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		s.log(c).WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// handleListWebhooks is a handler for listing the webhook subscriptions.
+func (s *Server) handleListWebhooks(c *gin.Context) {
+	webhooks, err := s.webhookService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		s.respondWebhookError(c, err, "Failed to list webhooks")
+		return
+	}
+
+	redacted := make([]*model.WebhookSubscription, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		redacted = append(redacted, withoutSecret(webhook))
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": redacted})
+}
+
+// handleCreateWebhook is a handler for registering a webhook subscription. The response is the only one
+// carrying the signing secret.
+func (s *Server) handleCreateWebhook(c *gin.Context) {
+	var input service.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := s.webhookService.CreateWebhook(c.Request.Context(), input)
+	if err != nil {
+		s.respondWebhookError(c, err, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// handleGetWebhook is a handler for fetching a webhook subscription.
+func (s *Server) handleGetWebhook(c *gin.Context) {
+	webhook, err := s.webhookService.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		s.respondWebhookError(c, err, "Failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, withoutSecret(webhook))
+}
+
+// handleUpdateWebhook is a handler for replacing a webhook subscription.
+func (s *Server) handleUpdateWebhook(c *gin.Context) {
+	var input service.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := s.webhookService.UpdateWebhook(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		s.respondWebhookError(c, err, "Failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, withoutSecret(webhook))
+}
+
+// handleDeleteWebhook is a handler for removing a webhook subscription.
+func (s *Server) handleDeleteWebhook(c *gin.Context) {
+	if err := s.webhookService.DeleteWebhook(c.Request.Context(), c.Param("id")); err != nil {
+		s.respondWebhookError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleTestWebhook is a handler for sending a test event to a webhook subscription.
+func (s *Server) handleTestWebhook(c *gin.Context) {
+	delivery, err := s.webhookService.TestWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		s.respondWebhookError(c, err, "Failed to test webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// handleListWebhookDeliveries is a handler for fetching the delivery history of a webhook subscription.
+func (s *Server) handleListWebhookDeliveries(c *gin.Context) {
+	deliveries, err := s.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		s.respondWebhookError(c, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+// receivedWebhook is a delivery attempt seen by webhookReceiver.
+type receivedWebhook struct {
+	at     time.Time
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver answers webhook deliveries with the given statuses in turn, then 200.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	receiver := &webhookReceiver{statuses: statuses}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		receiver.mu.Lock()
+		receiver.received = append(receiver.received, receivedWebhook{at: time.Now(), header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(receiver.statuses) > 0 {
+			status, receiver.statuses = receiver.statuses[0], receiver.statuses[1:]
+		}
+		receiver.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) attempts() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.received...)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		attempts    int
+		success     bool
+		lastStatus  int
+		errorString string
+	}{
+		{name: "retried until accepted", statuses: []int{503, 500}, attempts: 3, success: true, lastStatus: 200},
+		{name: "abandoned after max attempts", statuses: []int{500, 502, 503}, attempts: 3, success: false, lastStatus: 503, errorString: "receiver responded with 503 Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newWebhookReceiver(t, tt.statuses...)
+			ts := newTestServer(t, testutil.NewFakePUBG(t), "WEBHOOK_MAX_ATTEMPTS=3", "WEBHOOK_BACKOFF=20ms")
+
+			const secret = "s3cret"
+			var webhook model.WebhookSubscription
+			status := ts.do(t, http.MethodPost, "/webhooks", map[string]interface{}{
+				"url":    receiver.URL,
+				"secret": secret,
+				"events": []model.EventType{model.EventNewSeason},
+			}, &webhook)
+			if status != http.StatusCreated {
+				t.Fatalf("creating webhook: got status %d", status)
+			}
+
+			event := model.Event{ID: "event-1", Type: model.EventNewSeason, Time: time.Now().UTC(), Data: map[string]string{"seasonId": "s2"}}
+			ts.webhookService.HandleEvent(context.Background(), event)
+			// Events the subscription does not want are not delivered.
+			ts.webhookService.HandleEvent(context.Background(), model.Event{ID: "event-2", Type: model.EventRefreshFailed, Time: time.Now().UTC()})
+
+			var history struct {
+				Deliveries []model.WebhookDelivery `json:"deliveries"`
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for len(history.Deliveries) == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("delivery was never recorded")
+				}
+				time.Sleep(10 * time.Millisecond)
+				if status := ts.do(t, http.MethodGet, "/webhooks/"+webhook.ID+"/deliveries", nil, &history); status != http.StatusOK {
+					t.Fatalf("listing deliveries: got status %d", status)
+				}
+			}
+
+			if len(history.Deliveries) != 1 {
+				t.Fatalf("got %d deliveries, want 1", len(history.Deliveries))
+			}
+			delivery := history.Deliveries[0]
+			if delivery.EventID != event.ID || delivery.EventType != event.Type || delivery.WebhookID != webhook.ID {
+				t.Errorf("delivery %+v does not match event %s of webhook %s", delivery, event.ID, webhook.ID)
+			}
+			if delivery.Attempts != tt.attempts || delivery.Success != tt.success || delivery.StatusCode != tt.lastStatus || delivery.Error != tt.errorString {
+				t.Errorf("got attempts=%d success=%t status=%d error=%q, want attempts=%d success=%t status=%d error=%q",
+					delivery.Attempts, delivery.Success, delivery.StatusCode, delivery.Error, tt.attempts, tt.success, tt.lastStatus, tt.errorString)
+			}
+
+			attempts := receiver.attempts()
+			if len(attempts) != tt.attempts {
+				t.Fatalf("receiver got %d attempts, want %d", len(attempts), tt.attempts)
+			}
+			for i, attempt := range attempts {
+				timestamp := attempt.header.Get("X-Webhook-Timestamp")
+				mac := hmac.New(sha256.New, []byte(secret))
+				mac.Write([]byte(timestamp + "." + string(attempt.body)))
+				want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+				if got := attempt.header.Get("X-Webhook-Signature"); !hmac.Equal([]byte(got), []byte(want)) {
+					t.Errorf("attempt %d: signature %q, want %q", i+1, got, want)
+				}
+				if got := attempt.header.Get("X-Webhook-Delivery"); got != delivery.ID {
+					t.Errorf("attempt %d: delivery ID %q, want %q", i+1, got, delivery.ID)
+				}
+				if got := attempt.header.Get("X-Webhook-Event"); got != string(model.EventNewSeason) {
+					t.Errorf("attempt %d: event %q, want %q", i+1, got, model.EventNewSeason)
+				}
+			}
+
+			// The backoff doubles from 20ms on every retry.
+			backoff := 20 * time.Millisecond
+			for i := 1; i < len(attempts); i++ {
+				if gap := attempts[i].at.Sub(attempts[i-1].at); gap < backoff {
+					t.Errorf("retry %d came %v after the previous attempt, want at least %v", i, gap, backoff)
+				}
+				backoff *= 2
+			}
+		})
+	}
+}
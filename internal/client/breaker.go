@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/metrics"
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrCircuitOpen is returned without calling the PUBG API while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("pubgclient - Circuit open: PUBG API calls are suspended after repeated failures")
+
+// BreakerState is the state of the circuit breaker guarding the PUBG API.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a few trial calls through to find out whether the PUBG API recovered.
+	BreakerHalfOpen
+	// BreakerOpen rejects every call until the open timeout elapses.
+	BreakerOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerStatus is a snapshot of the circuit breaker for health reports.
+type BreakerStatus struct {
+	Enabled  bool       `json:"enabled"`
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`           // Consecutive failures while closed
+	OpenedAt *time.Time `json:"openedAt,omitempty"` // When the breaker last opened, while open
+	RetryAt  *time.Time `json:"retryAt,omitempty"`  // When trial calls are let through again, while open
+}
+
+// outcome classifies a PUBG API call for the circuit breaker.
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored // Calls cancelled by the caller say nothing about the PUBG API
+)
+
+// CircuitBreaker stops calls to the PUBG API after consecutive failures. Once open, it rejects calls for the open
+// timeout, then lets trial calls through half-open: enough successes close it again, a failure reopens it.
+type CircuitBreaker struct {
+	failureThreshold int           // Consecutive failures that open the breaker, disabled when 0
+	openTimeout      time.Duration // How long the breaker stays open before trial calls
+	halfOpenRequests int           // Successful trial calls needed to close the breaker, also the trial calls let through at once
+
+	mu                sync.Mutex
+	state             BreakerState
+	failures          int
+	openedAt          time.Time
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker. A failure threshold of 0 disables it.
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration, halfOpenRequests int) *CircuitBreaker {
+	if halfOpenRequests < 1 {
+		halfOpenRequests = 1
+	}
+	metrics.BreakerState.Set(float64(BreakerClosed))
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		halfOpenRequests: halfOpenRequests,
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+	return b.state
+}
+
+// Status returns a snapshot of the breaker.
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+
+	status := BreakerStatus{Enabled: b.failureThreshold > 0, State: b.state.String(), Failures: b.failures}
+	if b.state == BreakerOpen {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.openTimeout)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// allow returns ErrCircuitOpen if the call must not reach the PUBG API.
+func (b *CircuitBreaker) allow() error {
+	if b.failureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+
+	switch b.state {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.halfOpenInFlight >= b.halfOpenRequests-b.halfOpenSuccesses {
+			return ErrCircuitOpen
+		}
+		b.halfOpenInFlight++
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call let through by allow.
+func (b *CircuitBreaker) record(result outcome) {
+	if b.failureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		switch result {
+		case outcomeSuccess:
+			b.failures = 0
+		case outcomeFailure:
+			b.failures++
+			if b.failures >= b.failureThreshold {
+				b.transition(BreakerOpen)
+			}
+		}
+	case BreakerHalfOpen:
+		b.halfOpenInFlight--
+		switch result {
+		case outcomeSuccess:
+			b.halfOpenSuccesses++
+			if b.halfOpenSuccesses >= b.halfOpenRequests {
+				b.transition(BreakerClosed)
+			}
+		case outcomeFailure:
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+// expireOpen moves an open breaker to half-open once its open timeout elapsed. The caller holds the lock.
+func (b *CircuitBreaker) expireOpen() {
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.openTimeout {
+		b.transition(BreakerHalfOpen)
+	}
+}
+
+// transition enters a state and resets the counters of the previous one. The caller holds the lock.
+func (b *CircuitBreaker) transition(state BreakerState) {
+	b.state = state
+	b.failures = 0
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+	if state == BreakerOpen {
+		b.openedAt = time.Now()
+	}
+
+	metrics.BreakerState.Set(float64(state))
+	metrics.BreakerTransitions.WithLabelValues(state.String()).Inc()
+}
+
+// classify tells the circuit breaker whether a call shows the PUBG API failing: transport errors, rate limiting
+// and server errors do, client errors such as a missing resource do not.
+func classify(ctx context.Context, resp *resty.Response, err error) outcome {
+	if ctx.Err() != nil {
+		return outcomeIgnored
+	}
+	if err != nil {
+		return outcomeFailure
+	}
+	if status := resp.StatusCode(); status == 429 || status >= 500 {
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
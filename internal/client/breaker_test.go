@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// breakerStep is one call through the breaker, or the open timeout elapsing, followed by the expected state.
+type breakerStep struct {
+	action    string // "success", "failure" or "ignored" to let a call through and record it, "reject" for a call the breaker refuses, "expire" for the open timeout
+	wantState BreakerState
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name             string
+		failureThreshold int
+		halfOpenRequests int
+		steps            []breakerStep
+	}{
+		{
+			name:             "opens after consecutive failures",
+			failureThreshold: 3,
+			steps: []breakerStep{
+				{"failure", BreakerClosed},
+				{"failure", BreakerClosed},
+				{"failure", BreakerOpen},
+				{"reject", BreakerOpen},
+			},
+		},
+		{
+			name:             "a success resets the failures",
+			failureThreshold: 2,
+			steps: []breakerStep{
+				{"failure", BreakerClosed},
+				{"success", BreakerClosed},
+				{"failure", BreakerClosed},
+				{"failure", BreakerOpen},
+			},
+		},
+		{
+			name:             "cancelled calls are ignored",
+			failureThreshold: 2,
+			steps: []breakerStep{
+				{"failure", BreakerClosed},
+				{"ignored", BreakerClosed},
+				{"ignored", BreakerClosed},
+				{"failure", BreakerOpen},
+			},
+		},
+		{
+			name:             "half-open trial success closes",
+			failureThreshold: 1,
+			steps: []breakerStep{
+				{"failure", BreakerOpen},
+				{"expire", BreakerHalfOpen},
+				{"success", BreakerClosed},
+			},
+		},
+		{
+			name:             "half-open trial failure reopens",
+			failureThreshold: 1,
+			steps: []breakerStep{
+				{"failure", BreakerOpen},
+				{"expire", BreakerHalfOpen},
+				{"failure", BreakerOpen},
+				{"reject", BreakerOpen},
+			},
+		},
+		{
+			name:             "half-open needs every trial to succeed",
+			failureThreshold: 1,
+			halfOpenRequests: 2,
+			steps: []breakerStep{
+				{"failure", BreakerOpen},
+				{"expire", BreakerHalfOpen},
+				{"success", BreakerHalfOpen},
+				{"success", BreakerClosed},
+			},
+		},
+		{
+			name:             "disabled breaker never opens",
+			failureThreshold: 0,
+			steps: []breakerStep{
+				{"failure", BreakerClosed},
+				{"failure", BreakerClosed},
+				{"failure", BreakerClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker(tt.failureThreshold, time.Minute, tt.halfOpenRequests)
+			for i, step := range tt.steps {
+				switch step.action {
+				case "expire":
+					b.mu.Lock()
+					b.openedAt = b.openedAt.Add(-b.openTimeout)
+					b.mu.Unlock()
+				case "reject":
+					if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+						t.Fatalf("step %d: allow returned %v, want %v", i, err, ErrCircuitOpen)
+					}
+				default:
+					if err := b.allow(); err != nil {
+						t.Fatalf("step %d: allow returned %v, want the call let through", i, err)
+					}
+					b.record(map[string]outcome{"success": outcomeSuccess, "failure": outcomeFailure, "ignored": outcomeIgnored}[step.action])
+				}
+				if got := b.State(); got != step.wantState {
+					t.Fatalf("step %d (%s): state %s, want %s", i, step.action, got, step.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerLimitsHalfOpenTrials(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Minute, 2)
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.record(outcomeFailure)
+	b.mu.Lock()
+	b.openedAt = b.openedAt.Add(-b.openTimeout)
+	b.mu.Unlock()
+
+	// Two trial calls are let through at once, a third waits for their outcome.
+	for i := 0; i < 2; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatalf("trial %d: allow returned %v", i, err)
+		}
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow returned %v with every trial in flight, want %v", err, ErrCircuitOpen)
+	}
+
+	// A successful trial leaves one more to succeed, which is already in flight.
+	b.record(outcomeSuccess)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow returned %v with the remaining trial in flight, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestCircuitBreakerStatus(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Minute, 1)
+	if status := b.Status(); !status.Enabled || status.State != "closed" || status.OpenedAt != nil {
+		t.Errorf("got status %+v of a closed breaker", status)
+	}
+
+	_ = b.allow()
+	b.record(outcomeFailure)
+	status := b.Status()
+	if status.State != "open" || status.OpenedAt == nil || status.RetryAt.Sub(*status.OpenedAt) != time.Minute {
+		t.Errorf("got status %+v of an open breaker, want it to retry one open timeout after opening", status)
+	}
+
+	if status := NewCircuitBreaker(0, time.Minute, 1).Status(); status.Enabled {
+		t.Error("a breaker without failure threshold reported enabled")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	response := func(status int) *resty.Response {
+		return &resty.Response{RawResponse: &http.Response{StatusCode: status}}
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		resp *resty.Response
+		err  error
+		want outcome
+	}{
+		{name: "success", ctx: context.Background(), resp: response(http.StatusOK), want: outcomeSuccess},
+		{name: "not modified", ctx: context.Background(), resp: response(http.StatusNotModified), want: outcomeSuccess},
+		{name: "missing resource", ctx: context.Background(), resp: response(http.StatusNotFound), want: outcomeSuccess},
+		{name: "rate limited", ctx: context.Background(), resp: response(http.StatusTooManyRequests), want: outcomeFailure},
+		{name: "server error", ctx: context.Background(), resp: response(http.StatusBadGateway), want: outcomeFailure},
+		{name: "transport error", ctx: context.Background(), err: errors.New("connection refused"), want: outcomeFailure},
+		{name: "cancelled by the caller", ctx: cancelled, err: context.Canceled, want: outcomeIgnored},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classify(tt.ctx, tt.resp, tt.err); got != tt.want {
+				t.Errorf("classify = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/metrics"
+	"github.com/gbasileGP/pubg-leaderboard/internal/tracing"
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cachedResponse is the last successful response of a URL, kept to revalidate it with a conditional request.
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	body         []byte
+	usedAt       time.Time
+}
+
+// responseCache holds the validators and bodies of the latest PUBG API responses by URL. When full, the least
+// recently used response is dropped.
+type responseCache struct {
+	size int // Responses kept, conditional requests are disabled when 0
+
+	mu        sync.Mutex
+	responses map[string]*cachedResponse
+}
+
+// newResponseCache creates a response cache keeping up to size responses.
+func newResponseCache(size int) *responseCache {
+	return &responseCache{size: size, responses: make(map[string]*cachedResponse)}
+}
+
+// get returns the cached response of a URL, or nil.
+func (c *responseCache) get(url string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.responses[url]
+	if !ok {
+		return nil
+	}
+	cached.usedAt = time.Now()
+	return cached
+}
+
+// put caches a response carrying at least one validator.
+func (c *responseCache) put(url string, resp *resty.Response) {
+	etag, lastModified := resp.Header().Get("ETag"), resp.Header().Get("Last-Modified")
+	if c.size <= 0 || (etag == "" && lastModified == "") {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.responses[url]; !ok && len(c.responses) >= c.size {
+		var oldest string
+		for key, cached := range c.responses {
+			if oldest == "" || cached.usedAt.Before(c.responses[oldest].usedAt) {
+				oldest = key
+			}
+		}
+		delete(c.responses, oldest)
+	}
+	c.responses[url] = &cachedResponse{etag: etag, lastModified: lastModified, body: resp.Body(), usedAt: time.Now()}
+}
+
+// get sends a GET request to the PUBG API through the circuit breaker, asking for a gzipped response. A response
+// seen before is revalidated with its ETag and Last-Modified validators: on 304 Not Modified the cached body is
+// decoded into result and modified is false.
+// The call is counted in the metrics under endpoint.
+func (p *PUBGClient) get(ctx context.Context, endpoint, url string, result interface{}) (resp *resty.Response, modified bool, err error) {
+	ctx, span := tracing.Start(ctx, "pubg "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodGet, semconv.URLFull(url), attribute.String("peer.service", "pubg-api")))
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode()), attribute.Bool("pubg.modified", modified))
+		}
+		tracing.End(span, err)
+	}()
+
+	if err := p.breaker.allow(); err != nil {
+		return nil, false, err
+	}
+
+	req := p.client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/vnd.api+json").
+		SetHeader("Accept-Encoding", "gzip").
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", p.config.PubgAPIKey)).
+		SetResult(result)
+
+	cached := p.responses.get(url)
+	if cached != nil {
+		if cached.etag != "" {
+			req.SetHeader("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.SetHeader("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	start := time.Now()
+	resp, err = req.Get(url)
+	p.breaker.record(classify(ctx, resp, err))
+	observe(endpoint, resp, err, time.Since(start))
+	if statusErr := checkStatus(resp); statusErr != nil {
+		return resp, false, statusErr
+	}
+	if err != nil {
+		return resp, false, err
+	}
+
+	if resp.StatusCode() == http.StatusNotModified && cached != nil {
+		if err := json.Unmarshal(cached.body, result); err != nil {
+			return resp, false, fmt.Errorf("pubgclient - error unmarshalling cached response: %v", err)
+		}
+		return resp, false, nil
+	}
+	if resp.StatusCode() == http.StatusOK {
+		p.responses.put(url, resp)
+	}
+	return resp, true, nil
+}
+
+// checkStatus turns the error statuses of the PUBG API into errors.
+func checkStatus(resp *resty.Response) error {
+	switch status := resp.StatusCode(); {
+	case status == 401:
+		return fmt.Errorf("pubgclient - Unauthorized: API key invalid or missing")
+	case status == 404:
+		return ErrNotFound
+	case status == 415:
+		return fmt.Errorf("pubgclient - Unsupported media type: Content type incorrect or not specified")
+	case status == 429:
+		return fmt.Errorf("pubgclient - Too many requests")
+	case status >= 500:
+		return fmt.Errorf("pubgclient - Server error: %s", resp.Status())
+	}
+	return nil
+}
+
+// observe records a call to the PUBG API and the rate limit it reported in the metrics.
+func observe(endpoint string, resp *resty.Response, err error, duration time.Duration) {
+	metrics.PUBGRequestDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode())
+	}
+	metrics.PUBGRequests.WithLabelValues(endpoint, status).Inc()
+
+	if resp != nil {
+		if remaining, err := strconv.Atoi(resp.Header().Get("X-Ratelimit-Remaining")); err == nil {
+			metrics.PUBGRateLimitRemaining.Set(float64(remaining))
+		}
+	}
+}
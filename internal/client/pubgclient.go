@@ -1,7 +1,13 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/gbasileGP/pubg-leaderboard/internal/config"
 	"github.com/gbasileGP/pubg-leaderboard/internal/model"
@@ -10,50 +16,82 @@ import (
 )
 
 type PUBGClient struct {
-	client *resty.Client
-	config *config.Config
-	logger *logrus.Logger
+	client    *resty.Client
+	config    *config.Config
+	logger    *logrus.Logger
+	breaker   *CircuitBreaker
+	responses *responseCache
 }
 
 func NewPUBGClient(cfg *config.Config, logger *logrus.Logger) *PUBGClient {
-	client := resty.New()
+	// Every call is bounded even when the caller's context has no deadline.
+	client := resty.New().SetTimeout(cfg.PubgRequestTimeout)
 
 	if logger == nil {
 		logger = logrus.New()
 	}
 
 	return &PUBGClient{
-		client: client,
-		config: cfg,
-		logger: logger,
+		client:    client,
+		config:    cfg,
+		logger:    logger,
+		breaker:   NewCircuitBreaker(cfg.BreakerFailureThreshold, cfg.BreakerOpenTimeout, cfg.BreakerHalfOpenRequests),
+		responses: newResponseCache(cfg.PubgResponseCacheSize),
 	}
 }
 
-// GetCurrentSeason fetches the current PUBG season with retry logic and logging.
-func (p *PUBGClient) GetCurrentSeason() (*model.SeasonData, error) {
-	p.logger.Info("pubgclient - Fetching current PUBG season")
-	var seasonsResp model.SeasonsResponse
-	resp, err := p.client.R().
-		SetHeader("Accept", "application/vnd.api+json").
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", p.config.PubgAPIKey)).
-		SetResult(&seasonsResp).
-		Get(fmt.Sprintf("%s/seasons", p.config.PubgAPIEndpoint))
-
-	if resp.StatusCode() == 401 {
-		return nil, fmt.Errorf("pubgclient - Unauthorized: API key invalid or missing")
-	} else if resp.StatusCode() == 404 {
-		return nil, fmt.Errorf("pubgclient - Not Found: The specified resource was not found")
-	} else if resp.StatusCode() == 415 {
-		return nil, fmt.Errorf("pubgclient - Unsupported media type: Content type incorrect or not specified")
-	} else if resp.StatusCode() == 429 {
-		return nil, fmt.Errorf("pubgclient - Too many requests")
+// Breaker returns the circuit breaker guarding the PUBG API.
+func (p *PUBGClient) Breaker() *CircuitBreaker {
+	return p.breaker
+}
+
+// Shard returns the platform shard the client queries, the last path segment of the API endpoint
+// (for example "pc-na" for https://api.pubg.com/shards/pc-na).
+func (p *PUBGClient) Shard() string {
+	return path.Base(strings.TrimRight(p.config.PubgAPIEndpoint, "/"))
+}
+
+// Ping checks that the PUBG API is reachable by calling its status endpoint at the root of the API host, which
+// needs no API key. It bypasses the circuit breaker, so probes neither trip it nor are refused by it.
+func (p *PUBGClient) Ping(ctx context.Context) error {
+	endpoint, err := url.Parse(p.config.PubgAPIEndpoint)
+	if err != nil {
+		return fmt.Errorf("pubgclient - invalid API endpoint: %v", err)
 	}
+	statusURL := url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host, Path: "/status"}
+
+	start := time.Now()
+	resp, err := p.client.R().SetContext(ctx).Get(statusURL.String())
+	observe("status", resp, err, time.Since(start))
+	if err != nil {
+		return fmt.Errorf("pubgclient - status request failed: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("pubgclient - status endpoint answered %s", resp.Status())
+	}
+	return nil
+}
+
+// ErrNotFound is returned when the PUBG API has no resource at the requested path.
+var ErrNotFound = errors.New("pubgclient - Not Found: The specified resource was not found")
+
+// GetSeasons fetches every PUBG season of the shard with logging.
+func (p *PUBGClient) GetSeasons(ctx context.Context) ([]model.SeasonData, error) {
+	p.logger.Info("pubgclient - Fetching PUBG seasons")
+
+	var seasonsResp model.SeasonsResponse
+	_, _, err := p.get(ctx, "seasons", fmt.Sprintf("%s/seasons", p.config.PubgAPIEndpoint), &seasonsResp)
 	if err != nil {
 		p.logger.WithError(err).Error("pubgclient - PUBGClient request failed")
 		return nil, err
 	}
 
-	for _, season := range seasonsResp.Data {
+	return seasonsResp.Data, nil
+}
+
+// CurrentSeason picks the current season out of a list of seasons.
+func CurrentSeason(seasons []model.SeasonData) (*model.SeasonData, error) {
+	for _, season := range seasons {
 		if season.Attributes.IsCurrentSeason && !season.Attributes.IsOffseason {
 			return &season, nil
 		}
@@ -62,8 +100,26 @@ func (p *PUBGClient) GetCurrentSeason() (*model.SeasonData, error) {
 	return nil, fmt.Errorf("pubgclient - current season not found")
 }
 
+// GetCurrentSeason fetches the current PUBG season with retry logic and logging.
+func (p *PUBGClient) GetCurrentSeason(ctx context.Context) (*model.SeasonData, error) {
+	p.logger.Info("pubgclient - Fetching current PUBG season")
+	seasons, err := p.GetSeasons(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return CurrentSeason(seasons)
+}
+
 // GetSeasonStats fetches leaderboard stats for the given season and game mode with logging.
-func (p *PUBGClient) GetSeasonStats(seasonID, gameMode string) (*model.LeaderboardResponse, error) {
+func (p *PUBGClient) GetSeasonStats(ctx context.Context, seasonID, gameMode string) (*model.LeaderboardResponse, error) {
+	leaderboardResp, _, err := p.GetSeasonStatsIfModified(ctx, seasonID, gameMode)
+	return leaderboardResp, err
+}
+
+// GetSeasonStatsIfModified fetches leaderboard stats for the given season and game mode like GetSeasonStats, and
+// also reports whether they changed since the client last fetched them.
+func (p *PUBGClient) GetSeasonStatsIfModified(ctx context.Context, seasonID, gameMode string) (*model.LeaderboardResponse, bool, error) {
 	// Log the attempt to fetch season stats
 	p.logger.WithFields(logrus.Fields{
 		"seasonID": seasonID,
@@ -71,28 +127,13 @@ func (p *PUBGClient) GetSeasonStats(seasonID, gameMode string) (*model.Leaderboa
 	}).Info("pubgclient - Fetching season stats from PUBG API")
 
 	var leaderboardResp model.LeaderboardResponse
-	resp, err := p.client.R().
-		SetHeader("Accept", "application/vnd.api+json").
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", p.config.PubgAPIKey)).
-		SetResult(&leaderboardResp).
-		Get(fmt.Sprintf("%s/leaderboards/%s/%s", p.config.PubgAPIEndpoint, seasonID, gameMode))
-
-	if resp.StatusCode() == 401 {
-		return nil, fmt.Errorf("pubgclient - Unauthorized: API key invalid or missing")
-	} else if resp.StatusCode() == 404 {
-		return nil, fmt.Errorf("pubgclient - Not Found: The specified resource was not found")
-	} else if resp.StatusCode() == 415 {
-		return nil, fmt.Errorf("pubgclient - Unsupported media type: Content type incorrect or not specified")
-	} else if resp.StatusCode() == 429 {
-		return nil, fmt.Errorf("pubgclient - Too many requests")
-	}
-
+	resp, modified, err := p.get(ctx, "leaderboards", fmt.Sprintf("%s/leaderboards/%s/%s", p.config.PubgAPIEndpoint, seasonID, gameMode), &leaderboardResp)
 	if err != nil {
 		p.logger.WithError(err).WithFields(logrus.Fields{
 			"seasonID": seasonID,
 			"gameMode": gameMode,
 		}).Error("pubgclient - Error fetching season stats")
-		return nil, err
+		return nil, false, err
 	}
 
 	// Log successful retrieval with response status
@@ -100,7 +141,8 @@ func (p *PUBGClient) GetSeasonStats(seasonID, gameMode string) (*model.Leaderboa
 		"seasonID": seasonID,
 		"gameMode": gameMode,
 		"status":   resp.Status(),
+		"modified": modified,
 	}).Info("pubgclient - Successfully fetched season stats")
 
-	return &leaderboardResp, nil
+	return &leaderboardResp, modified, nil
 }
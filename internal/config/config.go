@@ -1,52 +1,469 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/scheduler"
+	"github.com/sirupsen/logrus"
 )
 
 // Config represents the configuration settings for the application.
 type Config struct {
-	AppPort         string // Port on which the app will run
-	RedisAddr       string // Redis server address
-	RedisPass       string // Redis password
-	RedisDB         int    // Redis database number
-	PubgAPIEndpoint string // PUBG API endpoint
-	PubgAPIKey      string // PUBG API key
-	MinioEndpoint   string // MinIO server endpoint
-	MinioAccessKey  string // MinIO access key
-	MinioSecretKey  string // MinIO secret key
-	BackupsBucket   string // MinIO bucket for backups
-	BackupsFile     string // Backup file name
-}
-
-// LoadConfig reads configuration from environment variables.
-func LoadConfig() (*Config, error) {
-	redisDB, err := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	AppPort         string   // Port on which the app will run
+	RedisAddr       string   // Redis server address
+	RedisPass       string   // Redis password
+	RedisDB         int      // Redis database number
+	PubgAPIEndpoint string   // PUBG API endpoint
+	PubgAPIKey      string   // PUBG API key
+	MinioEndpoint   string   // MinIO server endpoint
+	MinioAccessKey  string   // MinIO access key
+	MinioSecretKey  string   // MinIO secret key
+	BackupsBucket   string   // MinIO bucket for backups
+	BackupsFile     string   // Backup file name
+	GameModes       []string // Game modes whose leaderboards are refreshed, the first one backs /current-leaderboard
+
+	OpenAPIValidateRequests  bool // Reject requests that do not match the OpenAPI spec
+	OpenAPIValidateResponses bool // Validate responses against the OpenAPI spec (always on in gin test mode)
+
+	RankingFormulas map[string]map[string]float64 // Ranking formula name to stat weights
+
+	RefreshFailureThreshold int           // Consecutive refresh failures that trigger a refresh.failed event
+	WebhookMaxAttempts      int           // Delivery attempts per webhook event before giving up
+	WebhookBackoff          time.Duration // Delay before the first webhook retry, doubled on every further retry
+	WebhookTimeout          time.Duration // Timeout of a single webhook delivery attempt
+	WebhookAllowPrivate     bool          // Let webhooks deliver to loopback, link-local and private addresses
+
+	NotifierChannels []NotifierChannel // Chat channels receiving leaderboard announcements
+
+	SeasonCheckInterval       time.Duration // How often the current season is checked for a rollover
+	SeasonsCacheTTL           time.Duration // How long the list of seasons is cached
+	SeasonLeaderboardCacheTTL time.Duration // How long leaderboards of past seasons are cached
+
+	ShutdownTimeout time.Duration // Time given to the server and background work to stop on SIGTERM
+
+	RequestTimeout     time.Duration // Deadline of every API request except live streams, disabled when 0
+	PubgRequestTimeout time.Duration // Deadline of every call to the PUBG API
+
+	Schedules map[string]JobSchedule // Schedules of the background jobs: "season", "backup" and "leaderboard:<gameMode>"
+
+	RefreshLeases bool   // Run each scheduled job only on the replica holding its Redis lease
+	ReplicaID     string // Identifier this replica holds leases under, unique per process
+
+	FetchWaitTimeout time.Duration // How long requests wait for data missing from the cache to be fetched by another request
+	FetchLockTTL     time.Duration // Upper bound of a fetch from the PUBG API after a cache miss
+
+	LeaderboardStaleTTL time.Duration // How long past their fresh TTL leaderboards are kept to be served stale
+
+	BreakerFailureThreshold int           // Consecutive PUBG API failures that open the circuit breaker, disabled when 0
+	BreakerOpenTimeout      time.Duration // How long the circuit breaker stays open before trial calls
+	BreakerHalfOpenRequests int           // Successful trial calls that close the circuit breaker again
+
+	PubgResponseCacheSize int // PUBG API responses kept to be revalidated with conditional requests, disabled when 0
+
+	TracingExporter    string  // Where spans go: "none", "stdout" or "otlp"
+	TracingSampleRatio float64 // Share of the traces started by the service that are recorded
+
+	HealthCriticalChecks []string      // Readiness checks that must be up for the replica to be ready
+	HealthCheckTimeout   time.Duration // Deadline of the readiness checks
+	HealthMaxRefreshAge  time.Duration // Age of the last leaderboard refresh past which the refresh check is down, the leaderboard's cache TTL when 0
+
+	AdminToken string // Bearer token required by the /admin endpoints, which are disabled when empty
+
+	LogLevel              string // Lowest level logged: "trace", "debug", "info", "warn" or "error"
+	LogFormat             string // "json" or "text"
+	LogSamplingInitial    int    // Occurrences of a high-frequency message logged every second before sampling
+	LogSamplingThereafter int    // One in this many further occurrences is logged, all of them when 1
+}
+
+// HealthChecks are the readiness checks, any of which can be listed in HEALTH_CRITICAL_CHECKS.
+var HealthChecks = []string{"redis", "minio", "pubg", "cache", "refresh"}
+
+// JobSchedule configures when a background job runs.
+type JobSchedule struct {
+	Schedule   scheduler.Schedule // When the job runs, never when nil
+	Jitter     time.Duration      // Upper bound of a random delay added to every scheduled run
+	RunOnStart bool               // Run once at startup before following the schedule
+}
+
+// NotifierChannel is a Discord or Slack incoming webhook receiving leaderboard announcements.
+type NotifierChannel struct {
+	Name      string            // Name used in logs
+	Format    string            // "discord" or "slack"
+	URL       string            // Incoming webhook URL
+	GameMode  string            // Game mode announced, scheduled announcements use the default one and events of every game mode are announced when empty
+	Interval  time.Duration     // Period of scheduled announcements, none when 0
+	Events    []string          // Event types announced as they happen
+	Templates map[string]string // text/template overrides of the announcement parts, by part name
+}
+
+// LoadConfig reads the configuration from, in increasing precedence, the defaults, the YAML or JSON file named by
+// --config or CONFIG_FILE, environment variables and the command-line flags in args, then validates it. Every
+// problem found is reported in the error.
+func LoadConfig(args []string) (*Config, error) {
+	cfg, src, err := load(args)
+	if src == nil {
+		return nil, err
+	}
+	if err := errors.Join(err, cfg.Validate()); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Print writes the configuration LoadConfig would read from args as a YAML config file, noting where every
+// setting came from and redacting secrets. Settings that fail validation are still printed, then reported in
+// the error.
+func Print(w io.Writer, args []string) error {
+	cfg, src, err := load(args)
+	if src == nil {
+		return err
+	}
+	if printErr := src.print(w); printErr != nil {
+		return printErr
+	}
+	return errors.Join(err, cfg.Validate())
+}
+
+// load reads the configuration without validating it. It returns the source the settings were read from, unless
+// the flags or the config file could not be read.
+func load(args []string) (*Config, *source, error) {
+	src, err := newSource(args, os.LookupEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gameModes := src.list("GAME_MODES", "squad-fpp")
+	seasonCheckInterval := src.duration("SEASON_CHECK_INTERVAL", "1h")
+
+	rankingFormulas, err := parseRankingFormulas(src.get("RANKING_FORMULAS", ""))
+	src.fail(err)
+
+	notifierChannels, err := parseNotifierChannels(src.get("NOTIFIER_CHANNELS", ""))
+	src.fail(err)
+
+	schedules, err := parseSchedules(src.get("REFRESH_SCHEDULES", ""), gameModes, seasonCheckInterval)
+	src.fail(err)
+
+	replicaID, err := defaultReplicaID()
+	src.fail(err)
+
+	cfg := &Config{
+		AppPort:         src.get("APP_PORT", "8080"),
+		RedisAddr:       src.get("REDIS_ADDR", "redis-cluster:6379"),
+		RedisPass:       src.get("REDIS_PASS", "themagicword"),
+		RedisDB:         src.int("REDIS_DB", 0),
+		PubgAPIEndpoint: src.get("PUBG_API_ENDPOINT", "https://api.pubg.com/shards/pc-na"),
+		PubgAPIKey:      src.get("PUBG_API_KEY", ""),
+		MinioEndpoint:   src.get("MINIO_ENDPOINT", "minio:9000"),
+		MinioAccessKey:  src.get("MINIO_ACCESS", "minio"),
+		MinioSecretKey:  src.get("MINIO_SECRET", "minio123"),
+		BackupsBucket:   src.get("BACKUPS_BUCKET", "pubg-leaderboard"),
+		BackupsFile:     src.get("BACKUPS_FILE", "leaderboard-backup.json"),
+		GameModes:       gameModes,
+
+		OpenAPIValidateRequests:  src.bool("OPENAPI_VALIDATE_REQUESTS", false),
+		OpenAPIValidateResponses: src.bool("OPENAPI_VALIDATE_RESPONSES", false),
+
+		RankingFormulas: rankingFormulas,
+
+		RefreshFailureThreshold: src.int("REFRESH_FAILURE_THRESHOLD", 3),
+		WebhookMaxAttempts:      src.int("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookBackoff:          src.duration("WEBHOOK_BACKOFF", "1s"),
+		WebhookTimeout:          src.duration("WEBHOOK_TIMEOUT", "10s"),
+		WebhookAllowPrivate:     src.bool("WEBHOOK_ALLOW_PRIVATE", false),
+
+		NotifierChannels: notifierChannels,
+
+		SeasonCheckInterval:       seasonCheckInterval,
+		SeasonsCacheTTL:           src.duration("SEASONS_CACHE_TTL", "168h"),
+		SeasonLeaderboardCacheTTL: src.duration("SEASON_LEADERBOARD_CACHE_TTL", "168h"),
+
+		ShutdownTimeout: src.duration("SHUTDOWN_TIMEOUT", "30s"),
+
+		RequestTimeout:     src.duration("REQUEST_TIMEOUT", "30s"),
+		PubgRequestTimeout: src.duration("PUBG_REQUEST_TIMEOUT", "10s"),
+
+		Schedules: schedules,
+
+		RefreshLeases: src.bool("REFRESH_LEASES", true),
+		ReplicaID:     src.get("REPLICA_ID", replicaID),
+
+		FetchWaitTimeout: src.duration("FETCH_WAIT_TIMEOUT", "10s"),
+		FetchLockTTL:     src.duration("FETCH_LOCK_TTL", "30s"),
+
+		LeaderboardStaleTTL: src.duration("LEADERBOARD_STALE_TTL", "24h"),
+
+		BreakerFailureThreshold: src.int("PUBG_BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerOpenTimeout:      src.duration("PUBG_BREAKER_OPEN_TIMEOUT", "30s"),
+		BreakerHalfOpenRequests: src.int("PUBG_BREAKER_HALF_OPEN_REQUESTS", 1),
+
+		PubgResponseCacheSize: src.int("PUBG_RESPONSE_CACHE_SIZE", 64),
+
+		TracingExporter:    src.get("TRACING_EXPORTER", "none"),
+		TracingSampleRatio: src.float("TRACING_SAMPLE_RATIO", 1),
+
+		HealthCriticalChecks: src.list("HEALTH_CRITICAL_CHECKS", "redis"),
+		HealthCheckTimeout:   src.duration("HEALTH_CHECK_TIMEOUT", "2s"),
+		HealthMaxRefreshAge:  src.duration("HEALTH_MAX_REFRESH_AGE", "0"),
+
+		AdminToken: src.get("ADMIN_TOKEN", ""),
+
+		LogLevel:              src.get("LOG_LEVEL", "info"),
+		LogFormat:             src.get("LOG_FORMAT", "json"),
+		LogSamplingInitial:    src.int("LOG_SAMPLING_INITIAL", 10),
+		LogSamplingThereafter: src.int("LOG_SAMPLING_THEREAFTER", 100),
+	}
+
+	return cfg, src, src.err()
+}
+
+// Validate checks that the configuration is complete and consistent, reporting every problem found.
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf("config - "+format, args...))
+		}
+	}
+
+	check(c.PubgAPIKey != "", "PUBG_API_KEY is required")
+	endpoint, err := url.Parse(c.PubgAPIEndpoint)
+	check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "", "PUBG_API_ENDPOINT must be an http or https URL, got %q", c.PubgAPIEndpoint)
+	port, err := strconv.Atoi(c.AppPort)
+	check(err == nil && port > 0 && port <= 65535, "APP_PORT must be a port number, got %q", c.AppPort)
+	check(c.RedisAddr != "", "REDIS_ADDR is required")
+	check(c.MinioEndpoint != "", "MINIO_ENDPOINT is required")
+	check(c.BackupsBucket != "" && c.BackupsFile != "", "BACKUPS_BUCKET and BACKUPS_FILE are required")
+	check(len(c.GameModes) > 0, "GAME_MODES must list at least one game mode")
+	check(c.ReplicaID != "", "REPLICA_ID must not be empty")
+
+	check(c.RefreshFailureThreshold > 0, "REFRESH_FAILURE_THRESHOLD must be positive")
+	check(c.WebhookMaxAttempts > 0, "WEBHOOK_MAX_ATTEMPTS must be positive")
+	check(c.WebhookBackoff >= 0 && c.WebhookTimeout > 0, "WEBHOOK_BACKOFF must not be negative and WEBHOOK_TIMEOUT must be positive")
+	check(c.SeasonCheckInterval > 0, "SEASON_CHECK_INTERVAL must be positive")
+	check(c.SeasonsCacheTTL > 0 && c.SeasonLeaderboardCacheTTL > 0, "SEASONS_CACHE_TTL and SEASON_LEADERBOARD_CACHE_TTL must be positive")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	check(c.RequestTimeout >= 0 && c.PubgRequestTimeout > 0, "REQUEST_TIMEOUT must not be negative and PUBG_REQUEST_TIMEOUT must be positive")
+	check(c.FetchWaitTimeout > 0 && c.FetchLockTTL > 0, "FETCH_WAIT_TIMEOUT and FETCH_LOCK_TTL must be positive")
+	check(c.LeaderboardStaleTTL >= 0, "LEADERBOARD_STALE_TTL must not be negative")
+	check(c.BreakerFailureThreshold >= 0 && c.BreakerOpenTimeout > 0 && c.BreakerHalfOpenRequests >= 1, "PUBG_BREAKER_FAILURE_THRESHOLD must not be negative, PUBG_BREAKER_OPEN_TIMEOUT must be positive and PUBG_BREAKER_HALF_OPEN_REQUESTS at least 1")
+	check(c.PubgResponseCacheSize >= 0, "PUBG_RESPONSE_CACHE_SIZE must not be negative")
+
+	check(slices.Contains([]string{"none", "stdout", "otlp"}, c.TracingExporter), "TRACING_EXPORTER must be none, stdout or otlp, got %q", c.TracingExporter)
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+	for _, name := range c.HealthCriticalChecks {
+		check(slices.Contains(HealthChecks, name), "HEALTH_CRITICAL_CHECKS: unknown check %q, expected one of %s", name, strings.Join(HealthChecks, ", "))
+	}
+	check(c.HealthCheckTimeout > 0 && c.HealthMaxRefreshAge >= 0, "HEALTH_CHECK_TIMEOUT must be positive and HEALTH_MAX_REFRESH_AGE must not be negative")
+
+	_, err = logrus.ParseLevel(c.LogLevel)
+	check(err == nil, "LOG_LEVEL must be one of trace, debug, info, warn or error, got %q", c.LogLevel)
+	check(c.LogFormat == "json" || c.LogFormat == "text", "LOG_FORMAT must be json or text, got %q", c.LogFormat)
+	check(c.LogSamplingInitial >= 0 && c.LogSamplingThereafter >= 1, "LOG_SAMPLING_INITIAL must not be negative and LOG_SAMPLING_THEREAFTER must be at least 1")
+
+	return errors.Join(problems...)
+}
+
+// defaultReplicaID returns the host name followed by a random suffix, so that a restarted process with the same
+// host name does not inherit the leases of the previous one.
+func defaultReplicaID() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("config - failed to get host name for REPLICA_ID: %v", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("config - failed to generate REPLICA_ID: %v", err)
+	}
+	return hostname + "-" + hex.EncodeToString(suffix), nil
+}
+
+// parseSchedules resolves the schedule of every background job from a JSON object keyed by job name, for example
+// {"leaderboard":{"schedule":"*/5 * * * *","jitter":"30s"},"backup":{"schedule":"@daily"}}. The "leaderboard"
+// entry applies to every game mode and "leaderboard:<gameMode>" entries override it for one game mode. Fields
+// left out keep the defaults: the season is checked every SEASON_CHECK_INTERVAL and leaderboards are refreshed
+// every 10 minutes, both also at startup, and backups are not scheduled.
+func parseSchedules(value string, gameModes []string, seasonCheckInterval time.Duration) (map[string]JobSchedule, error) {
+	type definition struct {
+		Schedule   string `json:"schedule"`
+		Jitter     string `json:"jitter"`
+		RunOnStart *bool  `json:"runOnStart"`
+	}
+
+	var definitions map[string]definition
+	if strings.TrimSpace(value) != "" {
+		if err := json.Unmarshal([]byte(value), &definitions); err != nil {
+			return nil, fmt.Errorf("config - invalid REFRESH_SCHEDULES: %v", err)
+		}
+	}
+
+	jobs := map[string]bool{"season": true, "leaderboard": true, "backup": true}
+	for _, gameMode := range gameModes {
+		jobs["leaderboard:"+gameMode] = true
+	}
+	for name := range definitions {
+		if !jobs[name] {
+			return nil, fmt.Errorf("config - REFRESH_SCHEDULES: unknown job %q", name)
+		}
+	}
+
+	// apply overrides the fields of a schedule set in the definition of a job, if any.
+	apply := func(name string, schedule JobSchedule) (JobSchedule, error) {
+		definition, found := definitions[name]
+		if !found {
+			return schedule, nil
+		}
+		if definition.Schedule != "" {
+			parsed, err := scheduler.Parse(definition.Schedule)
+			if err != nil {
+				return schedule, fmt.Errorf("config - REFRESH_SCHEDULES job %q: %v", name, err)
+			}
+			schedule.Schedule = parsed
+		}
+		if definition.Jitter != "" {
+			jitter, err := time.ParseDuration(definition.Jitter)
+			if err != nil || jitter < 0 {
+				return schedule, fmt.Errorf("config - REFRESH_SCHEDULES job %q: invalid jitter %q", name, definition.Jitter)
+			}
+			schedule.Jitter = jitter
+		}
+		if definition.RunOnStart != nil {
+			schedule.RunOnStart = *definition.RunOnStart
+		}
+		return schedule, nil
+	}
+
+	schedules := make(map[string]JobSchedule, len(gameModes)+2)
+	var err error
+	if schedules["season"], err = apply("season", JobSchedule{Schedule: scheduler.Every(seasonCheckInterval), RunOnStart: true}); err != nil {
+		return nil, err
+	}
+	if schedules["backup"], err = apply("backup", JobSchedule{}); err != nil {
+		return nil, err
+	}
+
+	leaderboard, err := apply("leaderboard", JobSchedule{Schedule: scheduler.Every(10 * time.Minute), RunOnStart: true})
 	if err != nil {
 		return nil, err
 	}
+	for _, gameMode := range gameModes {
+		if schedules["leaderboard:"+gameMode], err = apply("leaderboard:"+gameMode, leaderboard); err != nil {
+			return nil, err
+		}
+	}
+
+	return schedules, nil
+}
+
+// parseNotifierChannels parses a JSON array of channels, for example
+// [{"name":"community","format":"discord","url":"https://discord.com/api/webhooks/...","interval":"1h"}].
+func parseNotifierChannels(value string) ([]NotifierChannel, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var definitions []struct {
+		Name      string            `json:"name"`
+		Format    string            `json:"format"`
+		URL       string            `json:"url"`
+		GameMode  string            `json:"gameMode"`
+		Interval  string            `json:"interval"`
+		Events    []string          `json:"events"`
+		Templates map[string]string `json:"templates"`
+	}
+	if err := json.Unmarshal([]byte(value), &definitions); err != nil {
+		return nil, fmt.Errorf("config - invalid NOTIFIER_CHANNELS: %v", err)
+	}
+
+	channels := make([]NotifierChannel, 0, len(definitions))
+	names := make(map[string]bool, len(definitions))
+	for _, definition := range definitions {
+		if definition.Name == "" || names[definition.Name] {
+			return nil, fmt.Errorf("config - notifier channels need a unique name, got %q", definition.Name)
+		}
+		names[definition.Name] = true
+
+		if definition.Format != "discord" && definition.Format != "slack" {
+			return nil, fmt.Errorf("config - notifier channel %q: format must be discord or slack", definition.Name)
+		}
+		if definition.URL == "" {
+			return nil, fmt.Errorf("config - notifier channel %q: url is required", definition.Name)
+		}
+
+		var interval time.Duration
+		if definition.Interval != "" {
+			parsed, err := time.ParseDuration(definition.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("config - notifier channel %q: invalid interval: %v", definition.Name, err)
+			}
+			interval = parsed
+		}
+
+		channels = append(channels, NotifierChannel{
+			Name:      definition.Name,
+			Format:    definition.Format,
+			URL:       definition.URL,
+			GameMode:  definition.GameMode,
+			Interval:  interval,
+			Events:    definition.Events,
+			Templates: definition.Templates,
+		})
+	}
+	return channels, nil
+}
+
+// parseRankingFormulas parses formulas written as "name=stat:weight,stat:weight;name=stat:weight",
+// for example "fragger=killsPerGame:10,kda:5;survivor=top10Rate:100,winRatio:50", or as a JSON object such as
+// {"fragger":{"killsPerGame":10,"kda":5}}.
+func parseRankingFormulas(value string) (map[string]map[string]float64, error) {
+	formulas := make(map[string]map[string]float64)
+
+	// Config files give formulas as an object of stat weights by formula name.
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		if err := json.Unmarshal([]byte(value), &formulas); err != nil {
+			return nil, fmt.Errorf("config - invalid RANKING_FORMULAS: %v", err)
+		}
+		return formulas, nil
+	}
 
-	return &Config{
-		AppPort:         getEnv("APP_PORT", "8080"),
-		RedisAddr:       getEnv("REDIS_ADDR", "redis-cluster:6379"),
-		RedisPass:       getEnv("REDIS_PASS", "themagicword"),
-		RedisDB:         redisDB,
-		PubgAPIEndpoint: getEnv("PUBG_API_ENDPOINT", "https://api.pubg.com/shards/pc-na"),
-		PubgAPIKey:      getEnv("PUBG_API_KEY", ""),
-		MinioEndpoint:   getEnv("MINIO_ENDPOINT", "minio:9000"),
-		MinioAccessKey:  getEnv("MINIO_ACCESS", "minio"),
-		MinioSecretKey:  getEnv("MINIO_SECRET", "minio123"),
-		BackupsBucket:   getEnv("BACKUPS_BUCKET", "pubg-leaderboard"),
-		BackupsFile:     getEnv("BACKUPS_FILE", "leaderboard-backup.json"),
-	}, nil
-}
-
-// getEnv reads an environment variable or returns a default value.
-func getEnv(key, defaultValue string) string {
-	value, exists := os.LookupEnv(key)
-	if !exists {
-		value = defaultValue
-	}
-	return value
+	for _, definition := range strings.Split(value, ";") {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		name, terms, found := strings.Cut(definition, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("config - invalid ranking formula %q: expected name=stat:weight,...", definition)
+		}
+
+		weights := make(map[string]float64)
+		for _, term := range strings.Split(terms, ",") {
+			stat, weight, found := strings.Cut(strings.TrimSpace(term), ":")
+			if !found {
+				return nil, fmt.Errorf("config - invalid term %q in ranking formula %q: expected stat:weight", term, name)
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+			if err != nil {
+				return nil, fmt.Errorf("config - invalid weight %q in ranking formula %q: %v", weight, name, err)
+			}
+			weights[strings.TrimSpace(stat)] = parsed
+		}
+		formulas[name] = weights
+	}
+	return formulas, nil
 }
@@ -0,0 +1,335 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origins of the value of a setting, from the lowest precedence to the highest.
+const (
+	originDefault = "default"
+	originFile    = "config file"
+	originEnv     = "env"
+	originFlag    = "flag"
+)
+
+// configFileSetting names the config file. It can only be set by an environment variable or a flag.
+const configFileSetting = "CONFIG_FILE"
+
+// secretSuffix marks the variant of a setting naming a file to read its value from, such as PUBG_API_KEY_FILE.
+const secretSuffix = "_FILE"
+
+// secretSettings hold credentials, redacted by Print.
+var secretSettings = map[string]bool{
+	"PUBG_API_KEY":      true,
+	"REDIS_PASS":        true,
+	"MINIO_ACCESS":      true,
+	"MINIO_SECRET":      true,
+	"ADMIN_TOKEN":       true,
+	"NOTIFIER_CHANNELS": true, // Incoming webhook URLs embed their token
+}
+
+// setting is the value a setting resolved to and where it came from.
+type setting struct {
+	name   string
+	value  string
+	origin string
+}
+
+// layer is a source of settings by name.
+type layer struct {
+	origin string
+	lookup func(name string) (string, bool)
+}
+
+// source resolves settings, named after their environment variable, from in increasing precedence their defaults,
+// the config file, environment variables and command-line flags. In every layer a setting can instead name a file
+// holding its value with the _FILE suffix, for Docker and Kubernetes secrets. Problems are collected so they are
+// all reported at once.
+type source struct {
+	layers     []layer             // From the highest precedence to the lowest
+	checkKnown []map[string]string // Settings of the config file and flags, which must all exist
+	known      map[string]bool     // Settings read, including their _FILE variant
+	resolved   []setting           // Settings read, in order
+	problems   []error
+}
+
+// newSource creates a source reading flags from args, written --pubg-api-key=value or --pubg-api-key value, the
+// environment through lookupEnv and the config file named by --config or CONFIG_FILE, if any.
+func newSource(args []string, lookupEnv func(string) (string, bool)) (*source, error) {
+	flags, err := parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &source{
+		layers: []layer{
+			{origin: originFlag, lookup: lookupMap(flags)},
+			{origin: originEnv, lookup: lookupEnv},
+		},
+		known: map[string]bool{configFileSetting: true},
+	}
+
+	path, found := flags[configFileSetting]
+	if !found {
+		path, found = lookupEnv(configFileSetting)
+	}
+	if found && path != "" {
+		file, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s.layers = append(s.layers, layer{origin: originFile + " " + path, lookup: lookupMap(file)})
+		s.checkKnown = append(s.checkKnown, file)
+	}
+	s.checkKnown = append(s.checkKnown, flags)
+
+	return s, nil
+}
+
+// lookupMap looks settings up in a map.
+func lookupMap(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, found := values[name]
+		return value, found
+	}
+}
+
+// settingName turns a flag or config file key, such as pubg-api-key or pubg_api_key, into the name of a setting.
+func settingName(key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	if name == "CONFIG" {
+		return configFileSetting
+	}
+	return name
+}
+
+// parseFlags reads flags written --name=value or --name value.
+func parseFlags(args []string) (map[string]string, error) {
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("config - unexpected argument %q, flags are written --name=value", arg)
+		}
+
+		key, value, found := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !found {
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("config - flag %s needs a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+		flags[settingName(key)] = value
+	}
+	return flags, nil
+}
+
+// readConfigFile reads the settings of a YAML or JSON config file, keyed by setting name in lower case, such as
+// pubg_api_key. Lists of values are read as comma-separated values and objects as JSON.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config - failed to read config file: %v", err)
+	}
+
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("config - config file %s: unknown format, expected .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config - config file %s: %v", path, err)
+	}
+
+	settings := make(map[string]string, len(values))
+	for key, value := range values {
+		text, err := settingText(value)
+		if err != nil {
+			return nil, fmt.Errorf("config - config file %s: %s: %v", path, key, err)
+		}
+		settings[settingName(key)] = text
+	}
+	return settings, nil
+}
+
+// settingText turns a value of a config file into the text of a setting.
+func settingText(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				// Lists of objects, such as notifier channels, are read as JSON.
+				encoded, err := json.Marshal(value)
+				return string(encoded), err
+			}
+			text, err := settingText(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// get resolves a setting, or returns defaultValue when no layer sets it.
+func (s *source) get(name, defaultValue string) string {
+	s.known[name] = true
+	s.known[name+secretSuffix] = true
+
+	for _, layer := range s.layers {
+		value, found := layer.lookup(name)
+		path, fromFile := layer.lookup(name + secretSuffix)
+		switch {
+		case found && fromFile:
+			s.fail(fmt.Errorf("config - %s and %s%s are both set by %s", name, name, secretSuffix, layer.origin))
+			return value
+		case found:
+			s.resolved = append(s.resolved, setting{name: name, value: value, origin: layer.origin})
+			return value
+		case fromFile:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				s.fail(fmt.Errorf("config - %s%s: %v", name, secretSuffix, err))
+				return defaultValue
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+			s.resolved = append(s.resolved, setting{name: name, value: value, origin: layer.origin + " " + name + secretSuffix})
+			return value
+		}
+	}
+
+	s.resolved = append(s.resolved, setting{name: name, value: defaultValue, origin: originDefault})
+	return defaultValue
+}
+
+// list resolves a comma-separated setting, dropping empty items.
+func (s *source) list(name, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(s.get(name, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// int resolves an integer setting.
+func (s *source) int(name string, defaultValue int) int {
+	value := s.get(name, strconv.Itoa(defaultValue))
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		s.fail(fmt.Errorf("config - %s: invalid integer %q", name, value))
+		return defaultValue
+	}
+	return parsed
+}
+
+// float resolves a decimal setting.
+func (s *source) float(name string, defaultValue float64) float64 {
+	value := s.get(name, strconv.FormatFloat(defaultValue, 'f', -1, 64))
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.fail(fmt.Errorf("config - %s: invalid number %q", name, value))
+		return defaultValue
+	}
+	return parsed
+}
+
+// bool resolves a boolean setting.
+func (s *source) bool(name string, defaultValue bool) bool {
+	value := s.get(name, strconv.FormatBool(defaultValue))
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		s.fail(fmt.Errorf("config - %s: invalid boolean %q, expected true or false", name, value))
+		return defaultValue
+	}
+	return parsed
+}
+
+// duration resolves a duration setting, written like 1h30m.
+func (s *source) duration(name, defaultValue string) time.Duration {
+	value := s.get(name, defaultValue)
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		s.fail(fmt.Errorf("config - %s: invalid duration %q, expected a value like 30s or 1h30m", name, value))
+		parsed, _ = time.ParseDuration(defaultValue)
+	}
+	return parsed
+}
+
+// fail records a problem with the settings.
+func (s *source) fail(err error) {
+	if err != nil {
+		s.problems = append(s.problems, err)
+	}
+}
+
+// err returns every problem met while reading the settings, including settings of the config file or flags
+// that do not exist.
+func (s *source) err() error {
+	problems := s.problems
+	for _, values := range s.checkKnown {
+		var unknown []string
+		for name := range values {
+			if !s.known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		slices.Sort(unknown)
+		for _, name := range unknown {
+			problems = append(problems, fmt.Errorf("config - unknown setting %s", name))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// print writes the settings read, with where each came from, as a YAML config file. Secrets are redacted.
+func (s *source) print(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "# Effective configuration, secrets redacted"); err != nil {
+		return err
+	}
+	for _, setting := range s.resolved {
+		value := setting.value
+		if secretSettings[setting.name] && value != "" {
+			value = "********"
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s # %s\n", strings.ToLower(setting.name), strconv.Quote(value), setting.origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
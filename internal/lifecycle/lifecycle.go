@@ -0,0 +1,158 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Service is a part of the application with background work that is started and stopped with it.
+type Service interface {
+	// Start launches the background work and returns once it is running.
+	Start(ctx context.Context) error
+	// Stop cancels the background work and waits for it to finish, giving up when ctx is done.
+	Stop(ctx context.Context) error
+}
+
+// Failing is implemented by services that can fail after they started, such as a server losing its listener.
+// The channel receives the failure, if any, and is closed once the service stopped.
+type Failing interface {
+	Failed() <-chan error
+}
+
+// namedService is a registered service with the name used in logs and errors.
+type namedService struct {
+	name    string
+	service Service
+}
+
+// Manager starts services in registration order and stops them in reverse order, so services registered
+// last, such as the HTTP server, stop first and stop depending on the others.
+type Manager struct {
+	logger          *logrus.Logger
+	shutdownTimeout time.Duration
+	services        []namedService
+}
+
+// NewManager creates a lifecycle manager that gives services shutdownTimeout in total to stop.
+func NewManager(logger *logrus.Logger, shutdownTimeout time.Duration) *Manager {
+	return &Manager{logger: logger, shutdownTimeout: shutdownTimeout}
+}
+
+// Add registers a service to be started after the ones already registered.
+func (m *Manager) Add(name string, service Service) {
+	m.services = append(m.services, namedService{name: name, service: service})
+}
+
+// Run starts every service and blocks until ctx is done or a service fails, then stops every started service
+// within the shutdown timeout. The returned error reports start failures, service failures and services that
+// did not stop in time.
+func (m *Manager) Run(ctx context.Context) error {
+	failed := make(chan error, len(m.services))
+
+	var started []namedService
+	var runErr error
+	for _, svc := range m.services {
+		if err := svc.service.Start(ctx); err != nil {
+			runErr = fmt.Errorf("lifecycle - failed to start %s: %w", svc.name, err)
+			break
+		}
+		m.logger.WithField("service", svc.name).Info("lifecycle: Run - Started service")
+		started = append(started, svc)
+
+		if failing, ok := svc.service.(Failing); ok {
+			go func(name string, failures <-chan error) {
+				if err, ok := <-failures; ok && err != nil {
+					failed <- fmt.Errorf("lifecycle - %s failed: %w", name, err)
+				}
+			}(svc.name, failing.Failed())
+		}
+	}
+
+	if runErr == nil {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("lifecycle: Run - Shutting down")
+		case runErr = <-failed:
+			m.logger.WithError(runErr).Error("lifecycle: Run - Service failed, shutting down")
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var stopErrs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		svc := started[i]
+		if err := svc.service.Stop(stopCtx); err != nil {
+			m.logger.WithError(err).WithField("service", svc.name).Error("lifecycle: Run - Failed to stop service")
+			stopErrs = append(stopErrs, fmt.Errorf("lifecycle - failed to stop %s: %w", svc.name, err))
+			continue
+		}
+		m.logger.WithField("service", svc.name).Info("lifecycle: Run - Stopped service")
+	}
+
+	return errors.Join(append([]error{runErr}, stopErrs...)...)
+}
+
+// Group runs background goroutines that share a context and are cancelled and awaited together. Services
+// use it to implement Stop.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewGroup creates a group whose goroutines run until the group is stopped.
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the context shared by the group's goroutines, done once the group is stopped.
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs fn in a goroutine of the group. It does nothing once the group is stopped.
+func (g *Group) Go(fn func(ctx context.Context)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+}
+
+// Stop cancels the group's context and waits for its goroutines to return, giving up when ctx is done.
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	g.stopped = true
+	g.mu.Unlock()
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
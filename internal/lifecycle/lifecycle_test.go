@@ -0,0 +1,263 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.uber.org/goleak"
+)
+
+// verifyNoLeaks fails the test if goroutines started during it are still running once it and its cleanups are
+// done.
+func verifyNoLeaks(t *testing.T) {
+	ignore := goleak.IgnoreCurrent()
+	t.Cleanup(func() { goleak.VerifyNone(t, ignore) })
+}
+
+func discardLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestGroupStopCancelsGoroutines(t *testing.T) {
+	verifyNoLeaks(t)
+
+	group := NewGroup()
+	started := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		group.Go(func(ctx context.Context) {
+			started <- struct{}{}
+			<-ctx.Done()
+		})
+	}
+	for i := 0; i < 10; i++ {
+		<-started
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := group.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if group.Context().Err() == nil {
+		t.Error("group context is not done after Stop")
+	}
+}
+
+func TestGroupStopGivesUpWhenContextEnds(t *testing.T) {
+	verifyNoLeaks(t)
+
+	group := NewGroup()
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	group.Go(func(ctx context.Context) {
+		defer close(returned)
+		<-release // Ignores cancellation
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := group.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The goroutine waiting for the group in Stop returns with the straggler.
+	close(release)
+	<-returned
+}
+
+func TestGroupGoAfterStop(t *testing.T) {
+	verifyNoLeaks(t)
+
+	group := NewGroup()
+	if err := group.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	ran := make(chan struct{}, 1)
+	group.Go(func(ctx context.Context) { ran <- struct{}{} })
+	if err := group.Stop(context.Background()); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+	select {
+	case <-ran:
+		t.Error("goroutine started after Stop")
+	default:
+	}
+}
+
+// fakeService runs a goroutine between Start and Stop, recording the calls in a shared log.
+type fakeService struct {
+	name     string
+	log      *callLog
+	startErr error
+	group    *Group
+}
+
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *callLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+func newFakeService(name string, log *callLog) *fakeService {
+	return &fakeService{name: name, log: log, group: NewGroup()}
+}
+
+func (s *fakeService) Start(ctx context.Context) error {
+	s.log.add("start " + s.name)
+	if s.startErr != nil {
+		return s.startErr
+	}
+	s.group.Go(func(ctx context.Context) { <-ctx.Done() })
+	return nil
+}
+
+func (s *fakeService) Stop(ctx context.Context) error {
+	s.log.add("stop " + s.name)
+	return s.group.Stop(ctx)
+}
+
+// failingService is a fakeService that can fail after it started. Like the HTTP server, it closes its failures
+// once stopped.
+type failingService struct {
+	*fakeService
+	failures chan error
+}
+
+func newFailingService(name string, log *callLog) *failingService {
+	return &failingService{fakeService: newFakeService(name, log), failures: make(chan error, 1)}
+}
+
+func (s *failingService) Stop(ctx context.Context) error {
+	defer close(s.failures)
+	return s.fakeService.Stop(ctx)
+}
+
+func (s *failingService) Failed() <-chan error {
+	return s.failures
+}
+
+func TestManagerRun(t *testing.T) {
+	startErr := errors.New("cannot listen")
+	serveErr := errors.New("listener closed")
+
+	tests := []struct {
+		name    string
+		setup   func(log *callLog) ([]Service, func(cancel context.CancelFunc))
+		calls   []string
+		wantErr error
+	}{
+		{
+			name: "stops in reverse order when the context ends",
+			setup: func(log *callLog) ([]Service, func(context.CancelFunc)) {
+				return []Service{newFakeService("store", log), newFakeService("jobs", log), newFakeService("server", log)},
+					func(cancel context.CancelFunc) { cancel() }
+			},
+			calls: []string{"start store", "start jobs", "start server", "stop server", "stop jobs", "stop store"},
+		},
+		{
+			name: "stops the started services when one fails to start",
+			setup: func(log *callLog) ([]Service, func(context.CancelFunc)) {
+				broken := newFakeService("server", log)
+				broken.startErr = startErr
+				return []Service{newFakeService("store", log), newFakeService("jobs", log), broken}, nil
+			},
+			calls:   []string{"start store", "start jobs", "start server", "stop jobs", "stop store"},
+			wantErr: startErr,
+		},
+		{
+			name: "shuts down when a service fails",
+			setup: func(log *callLog) ([]Service, func(context.CancelFunc)) {
+				server := newFailingService("server", log)
+				return []Service{newFakeService("store", log), server}, func(context.CancelFunc) { server.failures <- serveErr }
+			},
+			calls:   []string{"start store", "start server", "stop server", "stop store"},
+			wantErr: serveErr,
+		},
+		{
+			name: "stops a service that can fail but did not",
+			setup: func(log *callLog) ([]Service, func(context.CancelFunc)) {
+				return []Service{newFakeService("store", log), newFailingService("server", log)},
+					func(cancel context.CancelFunc) { cancel() }
+			},
+			calls: []string{"start store", "start server", "stop server", "stop store"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifyNoLeaks(t)
+
+			log := &callLog{}
+			services, trigger := tt.setup(log)
+			manager := NewManager(discardLogger(), time.Second)
+			for _, service := range services {
+				manager.Add("service", service)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			result := make(chan error, 1)
+			go func() { result <- manager.Run(ctx) }()
+
+			if trigger != nil {
+				// Wait for every service to start before triggering the shutdown.
+				for len(log.get()) < len(services) {
+					time.Sleep(time.Millisecond)
+				}
+				trigger(cancel)
+			}
+
+			select {
+			case err := <-result:
+				if (tt.wantErr == nil) != (err == nil) || !errors.Is(err, tt.wantErr) {
+					t.Errorf("Run returned %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run did not return")
+			}
+
+			if got := log.get(); !slices.Equal(got, tt.calls) {
+				t.Errorf("calls %v, want %v", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestManagerReportsServicesThatDoNotStop(t *testing.T) {
+	verifyNoLeaks(t)
+
+	log := &callLog{}
+	release := make(chan struct{})
+	stuck := newFakeService("stuck", log)
+	stuck.group.Go(func(ctx context.Context) { <-release })
+
+	manager := NewManager(discardLogger(), 20*time.Millisecond)
+	manager.Add("stuck", stuck)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := manager.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+}
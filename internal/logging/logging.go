@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// contextKey is the key of the request-scoped logger in a context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying logger, so code handling a request logs with its fields, such as its
+// request ID.
+func NewContext(ctx context.Context, logger *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the request-scoped logger carried by ctx, or fallback outside of requests. The logger is
+// bound to ctx so hooks can read it, such as the trace IDs added by tracing.LogHook.
+func FromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	if logger, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+		return logger.WithContext(ctx)
+	}
+	return fallback.WithContext(ctx)
+}
+
+// Log formats accepted by SetFormat.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// SetLevel sets the level of logger by name, such as "debug" or "warn".
+func SetLevel(logger *logrus.Logger, level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("logging - %v", err)
+	}
+	logger.SetLevel(parsed)
+	return nil
+}
+
+// SetFormat sets the format of logger: "json" for log collectors or "text" for reading in a terminal.
+func SetFormat(logger *logrus.Logger, format string) error {
+	switch format {
+	case FormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	case FormatText:
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return fmt.Errorf("logging - unknown format %q, expected %s or %s", format, FormatJSON, FormatText)
+	}
+	return nil
+}
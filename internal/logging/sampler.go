@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sampler thins out messages logged at a high rate, such as cache hits. Within every interval, the first initial
+// occurrences of a message are logged, then one in every thereafter.
+type Sampler struct {
+	initial    int
+	thereafter int
+	interval   time.Duration
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int // Occurrences of every message in the current interval
+}
+
+// NewSampler creates a sampler. A thereafter of 1 logs every message.
+func NewSampler(initial, thereafter int, interval time.Duration) *Sampler {
+	return &Sampler{
+		initial:    initial,
+		thereafter: max(thereafter, 1),
+		interval:   interval,
+		counts:     make(map[string]int),
+	}
+}
+
+// allow counts an occurrence of message and reports whether it is logged.
+func (s *Sampler) allow(message string) bool {
+	if s == nil || s.thereafter == 1 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.Sub(s.windowStart) >= s.interval {
+		clear(s.counts)
+		s.windowStart = now
+	}
+	s.counts[message]++
+	count := s.counts[message]
+	return count <= s.initial || (count-s.initial)%s.thereafter == 0
+}
+
+// Debug logs message at debug level through logger unless it is sampled out.
+func (s *Sampler) Debug(logger *logrus.Entry, message string) {
+	if logger.Logger.IsLevelEnabled(logrus.DebugLevel) && s.allow(message) {
+		logger.Debug(message)
+	}
+}
+
+// Info logs message at info level through logger unless it is sampled out.
+func (s *Sampler) Info(logger *logrus.Entry, message string) {
+	if logger.Logger.IsLevelEnabled(logrus.InfoLevel) && s.allow(message) {
+		logger.Info(message)
+	}
+}
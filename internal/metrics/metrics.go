@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The collectors below are registered with the default Prometheus registry and served on /metrics.
+
+var (
+	// HTTPRequests counts API requests by method, route template and status code.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "API requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes how long API requests take by method and route template.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of API requests by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+var (
+	// PUBGRequests counts calls to the PUBG API by endpoint and status code, "error" when no response came back.
+	PUBGRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pubg_api_requests_total",
+		Help: "Calls to the PUBG API by endpoint and status code.",
+	}, []string{"endpoint", "status"})
+
+	// PUBGRequestDuration observes how long calls to the PUBG API take by endpoint.
+	PUBGRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pubg_api_request_duration_seconds",
+		Help:    "Latency of calls to the PUBG API by endpoint.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// PUBGRateLimitRemaining is the number of calls left in the PUBG API rate limit window, as last reported.
+	PUBGRateLimitRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pubg_api_rate_limit_remaining",
+		Help: "Calls left in the current PUBG API rate limit window.",
+	})
+
+	// BreakerState is the state of the PUBG API circuit breaker: 0 closed, 1 half-open, 2 open.
+	BreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pubg_api_circuit_breaker_state",
+		Help: "State of the PUBG API circuit breaker: 0 closed, 1 half-open, 2 open.",
+	})
+
+	// BreakerTransitions counts the transitions of the PUBG API circuit breaker by the state entered.
+	BreakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pubg_api_circuit_breaker_transitions_total",
+		Help: "Transitions of the PUBG API circuit breaker by the state entered.",
+	}, []string{"state"})
+)
+
+var (
+	// Refreshes counts leaderboard refreshes by game mode, shard and result, "success" or "failure".
+	Refreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "leaderboard_refreshes_total",
+		Help: "Leaderboard refreshes by game mode, shard and result.",
+	}, []string{"game_mode", "shard", "result"})
+
+	// RefreshDuration observes how long leaderboard refreshes take by game mode and shard.
+	RefreshDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "leaderboard_refresh_duration_seconds",
+		Help:    "Duration of leaderboard refreshes by game mode and shard.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"game_mode", "shard"})
+
+	// RefreshLastSuccess is the unix time of the last successful leaderboard refresh by game mode and shard.
+	RefreshLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "leaderboard_refresh_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful leaderboard refresh by game mode and shard.",
+	}, []string{"game_mode", "shard"})
+
+	// LeaderboardPlayers is the number of players on the latest leaderboard stored by game mode and shard.
+	LeaderboardPlayers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "leaderboard_players",
+		Help: "Players on the latest stored leaderboard by game mode and shard.",
+	}, []string{"game_mode", "shard"})
+)
+
+// CacheRequests counts lookups in the Redis cache by cache, "season", "leaderboard" or "player_stats", and
+// result, "hit", "stale" or "miss".
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_requests_total",
+	Help: "Cache lookups by cache and result.",
+}, []string{"cache", "result"})
+
+var (
+	// BackupSize observes the size of leaderboard backups written to and read from MinIO by operation, "backup"
+	// or "restore".
+	BackupSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "leaderboard_backup_size_bytes",
+		Help:    "Size of leaderboard backups by operation.",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 8),
+	}, []string{"operation"})
+
+	// BackupDuration observes how long leaderboard backups and restores take by operation and result.
+	BackupDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "leaderboard_backup_duration_seconds",
+		Help:    "Duration of leaderboard backups and restores by operation and result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "result"})
+)
+
+// LiveSubscribersLagged counts live streams ended because their client fell too far behind the diffs.
+var LiveSubscribersLagged = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "live_subscribers_lagged_total",
+	Help: "Live streams ended because the client fell behind.",
+})
+
+// Result returns the result label of an operation that ended with err.
+func Result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
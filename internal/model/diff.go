@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// LeaderboardDiff describes how a leaderboard changed between two refreshes.
+type LeaderboardDiff struct {
+	GameMode    string        `json:"gameMode"`
+	ShardID     string        `json:"shardId"`
+	SeasonID    string        `json:"seasonId"`
+	GeneratedAt time.Time     `json:"generatedAt"`
+	RankChanges []RankChange  `json:"rankChanges"`
+	NewEntries  []PlayerEntry `json:"newEntries"`
+	Dropped     []PlayerEntry `json:"dropped"`
+}
+
+// RankChange is a player whose rank moved between two refreshes.
+type RankChange struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	OldRank int    `json:"oldRank"`
+	NewRank int    `json:"newRank"`
+}
+
+// PlayerEntry identifies a player and their rank on a leaderboard.
+type PlayerEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Rank int    `json:"rank"`
+}
+
+// Empty reports whether the diff holds no change.
+func (d *LeaderboardDiff) Empty() bool {
+	return len(d.RankChanges) == 0 && len(d.NewEntries) == 0 && len(d.Dropped) == 0
+}
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// EventType identifies a kind of leaderboard event.
+type EventType string
+
+const (
+	EventPlayerEnteredTop100  EventType = "player.entered_top100"
+	EventPlayerTierChanged    EventType = "player.tier_changed"
+	EventNewSeason            EventType = "season.new"
+	EventRefreshFailed        EventType = "refresh.failed"
+	EventWatchlistPlayerMoved EventType = "watchlist.player_moved"
+	EventLeaderboardRefreshed EventType = "leaderboard.refreshed"
+	EventSeasonArchived       EventType = "season.archived"
+	EventWebhookTest          EventType = "webhook.test"
+)
+
+// EventTypes lists the event types subscribers can register for.
+var EventTypes = []EventType{
+	EventPlayerEnteredTop100,
+	EventPlayerTierChanged,
+	EventNewSeason,
+	EventRefreshFailed,
+	EventWatchlistPlayerMoved,
+	EventLeaderboardRefreshed,
+	EventSeasonArchived,
+}
+
+// Event is something notable that happened to a leaderboard.
+type Event struct {
+	ID       string      `json:"id"`
+	Type     EventType   `json:"type"`
+	Time     time.Time   `json:"time"`
+	GameMode string      `json:"gameMode,omitempty"`
+	Data     interface{} `json:"data"`
+}
+
+// PlayerEventData is the payload of events about a single player.
+type PlayerEventData struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Rank       int    `json:"rank"`
+	OldRank    int    `json:"oldRank,omitempty"`
+	Tier       string `json:"tier,omitempty"`
+	SubTier    string `json:"subTier,omitempty"`
+	OldTier    string `json:"oldTier,omitempty"`
+	OldSubTier string `json:"oldSubTier,omitempty"`
+}
+
+// SeasonEventData is the payload of season events.
+type SeasonEventData struct {
+	SeasonID         string `json:"seasonId"`
+	PreviousSeasonID string `json:"previousSeasonId"`
+}
+
+// SeasonArchivedEventData is the payload of events about the final leaderboards of a season being archived.
+type SeasonArchivedEventData struct {
+	SeasonID  string   `json:"seasonId"`
+	ShardID   string   `json:"shardId"`
+	GameModes []string `json:"gameModes"`        // Game modes whose final leaderboard was archived
+	Failed    []string `json:"failed,omitempty"` // Game modes that could not be archived
+}
+
+// RefreshFailedEventData is the payload of refresh failure events.
+type RefreshFailedEventData struct {
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Error               string `json:"error"`
+}
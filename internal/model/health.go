@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Readiness is the result of the readiness checks of a replica. It is ready when every critical check is up.
+type Readiness struct {
+	Ready  bool                   `json:"ready"`
+	Checks map[string]HealthCheck `json:"checks"` // By check name: "redis", "minio", "pubg", "cache" and "refresh"
+}
+
+// HealthCheck is the status of one dependency of the service.
+type HealthCheck struct {
+	Status    string  `json:"status"`          // "up" or "down"
+	Critical  bool    `json:"critical"`        // Whether the replica is not ready while the check is down
+	Error     string  `json:"error,omitempty"` // Why the check is down
+	LatencyMs float64 `json:"latencyMs"`       // How long the check took
+
+	CircuitBreaker string                    `json:"circuitBreaker,omitempty"` // State of the PUBG API circuit breaker, for the pubg check
+	GameModes      map[string]GameModeHealth `json:"gameModes,omitempty"`      // Per game mode, for the cache and refresh checks
+}
+
+// Health check statuses.
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// GameModeHealth is the state of the cached leaderboard of a game mode.
+type GameModeHealth struct {
+	Cached        bool       `json:"cached"`                  // Whether the leaderboard is in Redis
+	LastRefreshAt *time.Time `json:"lastRefreshAt,omitempty"` // When the leaderboard was last fetched or found unchanged upstream
+	AgeSeconds    *float64   `json:"ageSeconds,omitempty"`    // Time since LastRefreshAt
+	MaxAgeSeconds *float64   `json:"maxAgeSeconds,omitempty"` // Age past which the refresh check is down, none when unset
+}
@@ -1,11 +1,15 @@
 package model
 
+import "time"
+
 // LeaderboardResponse represents the structure of the leaderboard response from the PUBG API.
 type LeaderboardResponse struct {
-	Data     LeaderboardData `json:"data"`
-	Links    Links           `json:"links"`
-	Meta     interface{}     `json:"meta"` // Meta can be an empty interface as it's often empty or varies
-	Included []PlayerData    `json:"included"`
+	Data      LeaderboardData `json:"data"`
+	Links     Links           `json:"links"`
+	Meta      interface{}     `json:"meta"` // Meta can be an empty interface as it's often empty or varies
+	Included  []PlayerData    `json:"included"`
+	FetchedAt *time.Time      `json:"fetchedAt,omitempty"` // When the leaderboard was fetched from the PUBG API
+	Stale     bool            `json:"stale,omitempty"`     // Served past its fresh TTL or from a backup while a refresh is retried
 }
 
 // LeaderboardData holds the data part of the leaderboard response.
@@ -48,9 +52,10 @@ type PlayerData struct {
 
 // PlayerAttribute contains attributes related to the player.
 type PlayerAttribute struct {
-	Name  string      `json:"name"`
-	Rank  int         `json:"rank"`
-	Stats PlayerStats `json:"stats"`
+	Name    string        `json:"name"`
+	Rank    int           `json:"rank"`
+	Stats   PlayerStats   `json:"stats"`
+	Derived *DerivedStats `json:"derived,omitempty"` // Computed by the service on refresh, absent in PUBG API responses
 }
 
 // PlayerStats holds statistics related to the player's performance.
@@ -68,6 +73,16 @@ type PlayerStats struct {
 	SubTier        string  `json:"subTier"`
 }
 
+// DerivedStats holds metrics computed from PlayerStats during a leaderboard refresh.
+type DerivedStats struct {
+	KillsPerGame  float64 `json:"killsPerGame"`
+	DamagePerGame float64 `json:"damagePerGame"`
+	Top10Rate     float64 `json:"top10Rate"`    // Estimated share of games finished in the top 10
+	WinRateLower  float64 `json:"winRateLower"` // Lower bound of the 95% Wilson interval of the win rate
+	WinRateUpper  float64 `json:"winRateUpper"` // Upper bound of the 95% Wilson interval of the win rate
+	Consistency   float64 `json:"consistency"`  // Top-10 rate shrunk towards zero for small samples, between 0 and 1
+}
+
 type GameModes struct {
 	Solo     string
 	Duo      string
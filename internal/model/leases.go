@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Lease is the state of the Redis lease that lets a single replica run a scheduled job.
+type Lease struct {
+	Job        string     `json:"job"`
+	Holder     string     `json:"holder,omitempty"`     // Replica holding the lease, empty when free
+	Token      int64      `json:"token,omitempty"`      // Fencing token, incremented every time the lease changes hands
+	AcquiredAt *time.Time `json:"acquiredAt,omitempty"` // When the holder acquired the lease
+	RenewedAt  *time.Time `json:"renewedAt,omitempty"`  // When the holder last renewed the lease
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`  // When the lease expires unless renewed
+	Local      bool       `json:"local"`                // Whether this replica holds the lease
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// RefreshReport highlights what a refresh changed on a game mode's leaderboard.
+type RefreshReport struct {
+	GameMode    string         `json:"gameMode"`
+	ShardID     string         `json:"shardId"`
+	SeasonID    string         `json:"seasonId"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Top         []ReportPlayer `json:"top"`
+	Movers      []ReportMover  `json:"movers"`      // Largest rank changes first
+	NewEntrants []ReportPlayer `json:"newEntrants"` // Best ranked first
+}
+
+// ReportPlayer is a player's standing in a refresh report.
+type ReportPlayer struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Rank       int     `json:"rank"`
+	RankPoints float64 `json:"rankPoints"`
+	Tier       string  `json:"tier"`
+	SubTier    string  `json:"subTier"`
+}
+
+// ReportMover is a player whose rank moved in a refresh. Change is positive when the player climbed.
+type ReportMover struct {
+	ReportPlayer
+	OldRank int `json:"oldRank"`
+	Change  int `json:"change"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// LeaderboardSummary holds aggregate statistics of a game mode's leaderboard.
+type LeaderboardSummary struct {
+	GameMode    string    `json:"gameMode"`
+	SeasonID    string    `json:"seasonId"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Players     int       `json:"players"`
+
+	Tiers                 []TierCount        `json:"tiers"`
+	RankPointsPercentiles map[string]float64 `json:"rankPointsPercentiles"` // Keyed p50, p75, p90, p95 and p99
+	RankPointsThresholds  map[string]float64 `json:"rankPointsThresholds"`  // Rank points needed for top10, top100 and top500
+
+	Kda           StatSummary `json:"kda"`
+	AverageDamage StatSummary `json:"averageDamage"`
+	WinRatio      StatSummary `json:"winRatio"`
+}
+
+// TierCount is the number of leaderboard players in a tier and sub-tier.
+type TierCount struct {
+	Tier    string `json:"tier"`
+	SubTier string `json:"subTier"`
+	Count   int    `json:"count"`
+}
+
+// StatSummary holds the central tendency of a stat across a leaderboard.
+type StatSummary struct {
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+}
+
+// SummaryPoint is the part of a summary tracked over time, recorded on every refresh.
+type SummaryPoint struct {
+	Timestamp             time.Time          `json:"timestamp"`
+	Players               int                `json:"players"`
+	RankPointsPercentiles map[string]float64 `json:"rankPointsPercentiles"`
+	RankPointsThresholds  map[string]float64 `json:"rankPointsThresholds"`
+}
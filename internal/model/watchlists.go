@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// Watchlist is a named roster of players tracked across refreshes.
+type Watchlist struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	PlayerIDs     []string  `json:"playerIds"`
+	MoveThreshold int       `json:"moveThreshold,omitempty"` // Rank change that emits an event, none when 0
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Watches reports whether the player is on the watchlist.
+func (w *Watchlist) Watches(playerID string) bool {
+	for _, watched := range w.PlayerIDs {
+		if watched == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// RankPoint is the rank of a player recorded at a refresh.
+type RankPoint struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Rank       int       `json:"rank"`
+	RankPoints float64   `json:"rankPoints"`
+}
+
+// WatchedPlayer is the standing of a watched player on a leaderboard. Deltas are positive when the player
+// climbed and nil when there is no earlier rank to compare with.
+type WatchedPlayer struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name,omitempty"`
+	OnLeaderboard    bool    `json:"onLeaderboard"`
+	Rank             int     `json:"rank,omitempty"`
+	RankPoints       float64 `json:"rankPoints,omitempty"`
+	Tier             string  `json:"tier,omitempty"`
+	SubTier          string  `json:"subTier,omitempty"`
+	DeltaLastRefresh *int    `json:"deltaLastRefresh"`
+	DeltaSince       *int    `json:"deltaSince,omitempty"`
+}
+
+// WatchlistLeaderboard is the leaderboard of a game mode restricted to the players of a watchlist.
+type WatchlistLeaderboard struct {
+	Watchlist *Watchlist      `json:"watchlist"`
+	GameMode  string          `json:"gameMode"`
+	Since     *time.Time      `json:"since,omitempty"`
+	Players   []WatchedPlayer `json:"players"`
+}
+
+// WatchlistMoveEventData is the payload of events about a watched player moving more than the threshold.
+type WatchlistMoveEventData struct {
+	WatchlistID   string          `json:"watchlistId"`
+	WatchlistName string          `json:"watchlistName"`
+	Player        PlayerEventData `json:"player"`
+}
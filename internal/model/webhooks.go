@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// WebhookSubscription is a URL receiving signed event payloads.
+type WebhookSubscription struct {
+	ID        string      `json:"id"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret,omitempty"` // HMAC-SHA256 key for the X-Webhook-Signature header
+	Events    []EventType `json:"events"`           // Event types delivered, all of them when empty
+	Active    bool        `json:"active"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// Wants reports whether the subscription receives events of the given type.
+func (w *WebhookSubscription) Wants(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, wanted := range w.Events {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records the outcome of delivering an event to a subscription.
+type WebhookDelivery struct {
+	ID         string    `json:"id"`
+	WebhookID  string    `json:"webhookId"`
+	EventID    string    `json:"eventId"`
+	EventType  EventType `json:"eventType"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Success    bool      `json:"success"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
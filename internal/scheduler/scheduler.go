@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// Schedule tells when a job runs next.
+type Schedule interface {
+	// Next returns the first run time after the given time.
+	Next(after time.Time) time.Time
+}
+
+// every runs a job at a fixed interval.
+type every struct {
+	interval time.Duration
+}
+
+// Next returns the time one interval after the given time.
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(e.interval)
+}
+
+// Every returns a schedule running a job at a fixed interval.
+func Every(interval time.Duration) Schedule {
+	return every{interval: interval}
+}
+
+// Parse reads a schedule written as a duration such as "10m", or as a standard cron expression such as
+// "*/10 * * * *", "@daily" or "@every 1h30m".
+func Parse(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if interval, err := time.ParseDuration(expression); err == nil {
+		if interval <= 0 {
+			return nil, fmt.Errorf("scheduler - interval must be positive, got %q", expression)
+		}
+		return Every(interval), nil
+	}
+
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler - invalid schedule %q: expected a duration or a cron expression: %v", expression, err)
+	}
+	return schedule, nil
+}
+
+// maxIntervalHorizon and maxIntervalRuns bound the runs of a cron schedule looked at by MaxInterval.
+const (
+	maxIntervalHorizon = 366 * 24 * time.Hour
+	maxIntervalRuns    = 10000
+)
+
+// MaxInterval returns the longest gap between two consecutive runs of the schedule. Cron schedules are looked
+// at over their runs of the coming year.
+func MaxInterval(schedule Schedule) time.Duration {
+	if e, ok := schedule.(every); ok {
+		return e.interval
+	}
+
+	start := time.Now()
+	previous := schedule.Next(start)
+	var longest time.Duration
+	for i := 0; i < maxIntervalRuns && !previous.IsZero() && previous.Sub(start) < maxIntervalHorizon; i++ {
+		next := schedule.Next(previous)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(previous); gap > longest {
+			longest = gap
+		}
+		previous = next
+	}
+	return longest
+}
+
+// Job is work run on a schedule.
+type Job struct {
+	Name       string                          // Name used in logs
+	Schedule   Schedule                        // When the job runs
+	Jitter     time.Duration                   // Upper bound of a random delay added to every scheduled run
+	RunOnStart bool                            // Run once as soon as the job starts, before following the schedule
+	Run        func(ctx context.Context) error // The work, cancelled when the job stops
+}
+
+// TTL returns how long data written by the job should be cached: twice the longest gap between runs plus the
+// jitter, so the data outlives one failed run and never expires before the next run that could replace it.
+// Jobs without a schedule return 0, as their data is never replaced and should not expire.
+func (j Job) TTL() time.Duration {
+	if j.Schedule == nil {
+		return 0
+	}
+	return 2*MaxInterval(j.Schedule) + j.Jitter
+}
+
+// Run runs the job on its schedule until ctx is done.
+func Run(ctx context.Context, logger *logrus.Logger, job Job) {
+	jobLogger := logger.WithField("job", job.Name)
+
+	if job.RunOnStart {
+		if !runOnce(ctx, jobLogger, job) {
+			return
+		}
+	}
+
+	for {
+		next := job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			jobLogger.Warn("scheduler: Run - Schedule has no further runs")
+			return
+		}
+		if job.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(job.Jitter))))
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !runOnce(ctx, jobLogger, job) {
+			return
+		}
+	}
+}
+
+// runOnce runs the job and logs the outcome. It returns false when ctx was cancelled during the run.
+func runOnce(ctx context.Context, logger *logrus.Entry, job Job) bool {
+	start := time.Now()
+	err := job.Run(ctx)
+	if ctx.Err() != nil {
+		logger.Info("scheduler: Run - Job cancelled")
+		return false
+	}
+	if err != nil {
+		logger.WithError(err).Error("scheduler: Run - Job failed")
+	} else {
+		logger.WithField("duration", time.Since(start).String()).Info("scheduler: Run - Job completed")
+	}
+	return true
+}
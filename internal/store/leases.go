@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// leaseKey is the hash holding the lease of a job. The job name is a hash tag so the lease and its fencing
+// token live in the same cluster slot and can be updated by one script.
+func leaseKey(job string) string {
+	return "lease:{" + job + "}"
+}
+
+// leaseTokenKey is the counter of the fencing tokens handed out for a job.
+func leaseTokenKey(job string) string {
+	return "lease_token:{" + job + "}"
+}
+
+// acquireLeaseScript acquires a free lease with a new fencing token, or renews a lease already held by the
+// same holder. It returns the fencing token, or 0 when another holder has the lease.
+var acquireLeaseScript = redis.NewScript(`
+local holder = redis.call('HGET', KEYS[1], 'holder')
+if holder and holder ~= ARGV[1] then
+	return 0
+end
+if not holder then
+	local token = redis.call('INCR', KEYS[2])
+	redis.call('HSET', KEYS[1], 'holder', ARGV[1], 'token', token, 'acquiredAt', ARGV[3])
+end
+redis.call('HSET', KEYS[1], 'renewedAt', ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return tonumber(redis.call('HGET', KEYS[1], 'token'))
+`)
+
+// releaseLeaseScript deletes a lease if it is still held by the given holder.
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'holder') == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// AcquireLease acquires or renews the lease of a job for holder, expiring after ttl unless renewed. It returns
+// the fencing token of the lease, and false if another holder has it.
+func (rc *RedisClient) AcquireLease(ctx context.Context, job, holder string, ttl time.Duration) (int64, bool, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	token, err := acquireLeaseScript.Run(ctx, rc.Client, []string{leaseKey(job), leaseTokenKey(job)}, holder, ttl.Milliseconds(), now).Int64()
+	if err != nil {
+		return 0, false, fmt.Errorf("redisclient - error acquiring lease of %s: %v", job, err)
+	}
+	return token, token != 0, nil
+}
+
+// ReleaseLease frees the lease of a job if holder still has it.
+func (rc *RedisClient) ReleaseLease(ctx context.Context, job, holder string) error {
+	if err := releaseLeaseScript.Run(ctx, rc.Client, []string{leaseKey(job)}, holder).Err(); err != nil {
+		return fmt.Errorf("redisclient - error releasing lease of %s: %v", job, err)
+	}
+	return nil
+}
+
+// GetLease retrieves the lease of a job, returning ErrCacheMiss if no replica holds it.
+func (rc *RedisClient) GetLease(ctx context.Context, job string) (*model.Lease, error) {
+	pipe := rc.Client.Pipeline()
+	fieldsCmd := pipe.HGetAll(ctx, leaseKey(job))
+	ttlCmd := pipe.PTTL(ctx, leaseKey(job))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	fields := fieldsCmd.Val()
+	if fields["holder"] == "" {
+		return nil, ErrCacheMiss
+	}
+
+	token, err := strconv.ParseInt(fields["token"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("redisclient - invalid fencing token of lease %s: %v", job, err)
+	}
+
+	lease := &model.Lease{Job: job, Holder: fields["holder"], Token: token}
+	if acquiredAt, err := time.Parse(time.RFC3339Nano, fields["acquiredAt"]); err == nil {
+		lease.AcquiredAt = &acquiredAt
+	}
+	if renewedAt, err := time.Parse(time.RFC3339Nano, fields["renewedAt"]); err == nil {
+		lease.RenewedAt = &renewedAt
+	}
+	if ttl := ttlCmd.Val(); ttl > 0 {
+		expiresAt := time.Now().Add(ttl).UTC()
+		lease.ExpiresAt = &expiresAt
+	}
+	return lease, nil
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fetchLockKey is the lock held by the replica fetching the data of a cache key from the PUBG API.
+func fetchLockKey(key string) string {
+	return "fetch_lock:" + key
+}
+
+// releaseLockScript deletes a lock if it is still held by the given holder.
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// AcquireFetchLock takes the fetch lock of a cache key for holder, expiring after ttl. It returns false if
+// another holder has the lock.
+func (rc *RedisClient) AcquireFetchLock(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	acquired, err := rc.Client.SetNX(ctx, fetchLockKey(key), holder, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redisclient - error acquiring fetch lock of %s: %v", key, err)
+	}
+	return acquired, nil
+}
+
+// ReleaseFetchLock frees the fetch lock of a cache key if holder still has it.
+func (rc *RedisClient) ReleaseFetchLock(ctx context.Context, key, holder string) error {
+	if err := releaseLockScript.Run(ctx, rc.Client, []string{fetchLockKey(key)}, holder).Err(); err != nil {
+		return fmt.Errorf("redisclient - error releasing fetch lock of %s: %v", key, err)
+	}
+	return nil
+}
@@ -11,10 +11,16 @@ type MinioClient struct {
 
 // NewMinioClient initializes a new MinIO client.
 func NewMinioClient(endpoint, accessKeyID, secretAccessKey string, useSSL bool) (*MinioClient, error) {
-	// Initialize a new MinIO client.
+	transport, err := minio.DefaultTransport(useSSL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize a new MinIO client, tracing its requests.
 	client, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
-		Secure: useSSL,
+		Creds:     credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure:    useSSL,
+		Transport: tracingTransport(transport),
 	})
 	if err != nil {
 		return nil, err
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gbasileGP/pubg-leaderboard/internal/model"
@@ -13,19 +14,22 @@ import (
 
 var ErrCacheMiss = errors.New("data not found in Redis")
 
+// ErrStaleFence is returned by fenced writes that carry a lower fencing token than the data they would replace.
+var ErrStaleFence = errors.New("data written under a newer fencing token")
+
 type RedisClient struct {
 	Client *redis.ClusterClient
 }
 
 // NewRedisClient creates a new Redis Cluster client and checks the connection.
 func NewRedisClient(addrs []string, password string, db int) (*RedisClient, error) {
-	fmt.Println("Creating Redis Cluster Client with addresses:", addrs) // Debug print
-
 	clusterClient := redis.NewClusterClient(&redis.ClusterOptions{
 		Addrs:    addrs,
 		Password: password,
 	})
 
+	clusterClient.AddHook(tracingHook{})
+
 	// Check the connection by sending a PING command to one of the cluster nodes
 	_, err := clusterClient.Ping(context.Background()).Result()
 	if err != nil {
@@ -41,9 +45,29 @@ func (rc *RedisClient) Ping(ctx context.Context) error {
 	return rc.Client.Ping(ctx).Err()
 }
 
-// GetLeaderboard retrieves the leaderboard data from Redis.
-func (rc *RedisClient) GetLeaderboard(ctx context.Context) (*model.LeaderboardResponse, error) {
-	data, err := rc.Client.Get(ctx, "leaderboard").Result()
+// legacyGameMode is the only game mode cached before game modes became configurable. It keeps its unprefixed
+// keys so caches written by earlier versions stay valid across upgrades.
+const legacyGameMode = "squad-fpp"
+
+// leaderboardKey is the key holding the leaderboard of a game mode.
+func leaderboardKey(gameMode string) string {
+	if gameMode == legacyGameMode {
+		return "leaderboard"
+	}
+	return "leaderboard:" + gameMode
+}
+
+// playerStatsKey is the key of the hash holding a player's stats in a game mode.
+func playerStatsKey(gameMode, playerID string) string {
+	if gameMode == legacyGameMode {
+		return "player_stats:" + playerID
+	}
+	return "player_stats:" + gameMode + ":" + playerID
+}
+
+// GetLeaderboard retrieves the leaderboard data of a game mode from Redis.
+func (rc *RedisClient) GetLeaderboard(ctx context.Context, gameMode string) (*model.LeaderboardResponse, error) {
+	data, err := rc.Client.Get(ctx, leaderboardKey(gameMode)).Result()
 	if err == redis.Nil {
 		return nil, ErrCacheMiss
 	} else if err != nil {
@@ -59,20 +83,81 @@ func (rc *RedisClient) GetLeaderboard(ctx context.Context) (*model.LeaderboardRe
 	return leaderboard, nil
 }
 
-// UpdateLeaderboard updates and structures the leaderboard data in Redis.
-func (rc *RedisClient) UpdateLeaderboard(ctx context.Context, leaderboardData *model.LeaderboardResponse) error {
+// leaderboardFenceKey is the key holding the fencing token of the last leased run that wrote the leaderboard of
+// a game mode. The leaderboard key is its hash tag, so both live in the same cluster slot and are written by one
+// script.
+func leaderboardFenceKey(gameMode string) string {
+	return "{" + leaderboardKey(gameMode) + "}:fence"
+}
+
+// fencedSetScript sets a key unless its fence holds a higher fencing token, then records the token in the fence.
+// A token of 0 writes unconditionally and leaves the fence as it is. It returns 0 when the fence rejected the
+// write.
+var fencedSetScript = redis.NewScript(`
+local token = tonumber(ARGV[1])
+if token > 0 and tonumber(redis.call('GET', KEYS[2]) or 0) > token then
+	return 0
+end
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[3], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[3])
+end
+if token > 0 then
+	redis.call('SET', KEYS[2], token)
+	if ttl > 0 then
+		redis.call('PEXPIRE', KEYS[2], ttl)
+	end
+end
+return 1
+`)
+
+// fencedExpireScript extends a key like fencedSetScript sets it. It returns 0 when the fence rejected the write
+// and -1 when the key does not exist.
+var fencedExpireScript = redis.NewScript(`
+local token = tonumber(ARGV[1])
+if token > 0 and tonumber(redis.call('GET', KEYS[2]) or 0) > token then
+	return 0
+end
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+if token > 0 then
+	redis.call('SET', KEYS[2], token)
+	if ttl > 0 then
+		redis.call('PEXPIRE', KEYS[2], ttl)
+	end
+end
+return 1
+`)
+
+// UpdateLeaderboard updates and structures the leaderboard data of a game mode in Redis, expiring after ttl.
+// Leased runs pass the fencing token of their lease, and the write fails with ErrStaleFence if a run with a newer
+// token already wrote the leaderboard. A token of 0 writes unconditionally.
+func (rc *RedisClient) UpdateLeaderboard(ctx context.Context, gameMode string, leaderboardData *model.LeaderboardResponse, fence int64, ttl time.Duration) error {
 	// Serialize the entire leaderboard data
 	leaderboardJSON, err := json.Marshal(leaderboardData)
 	if err != nil {
 		return fmt.Errorf("redisclient - error marshaling entire leaderboard data: %v", err)
 	}
 
+	// The leaderboard is checked against its fence and set in one step, so a run that lost its lease cannot
+	// replace the leaderboard of the run that took it over.
+	written, err := fencedSetScript.Run(ctx, rc.Client, []string{leaderboardKey(gameMode), leaderboardFenceKey(gameMode)}, fence, ttl.Milliseconds(), leaderboardJSON).Int64()
+	if err != nil {
+		return fmt.Errorf("redisclient - error updating leaderboard in Redis: %v", err)
+	} else if written == 0 {
+		return ErrStaleFence
+	}
+
 	// Begin a new Redis transaction.
 	pipe := rc.Client.TxPipeline()
 
-	// Set the entire leaderboard.
-	pipe.Set(ctx, "leaderboard", leaderboardJSON, 10*time.Minute)
-
 	// Store each player's stats in a separate hash.
 	for _, player := range leaderboardData.Included {
 		playerStatsJSON, err := json.Marshal(player.Attributes)
@@ -81,23 +166,74 @@ func (rc *RedisClient) UpdateLeaderboard(ctx context.Context, leaderboardData *m
 		}
 
 		// Set the player stats hash.
-		pipe.HSet(ctx, "player_stats:"+player.ID, "stats", playerStatsJSON)
-		// Optionally set an expiration time on each hash.
-		pipe.Expire(ctx, "player_stats:"+player.ID, 10*time.Minute)
+		pipe.HSet(ctx, playerStatsKey(gameMode, player.ID), "stats", playerStatsJSON)
+		// Expire each hash together with the leaderboard.
+		pipe.Expire(ctx, playerStatsKey(gameMode, player.ID), ttl)
 	}
 
 	// Execute the transaction.
 	_, err = pipe.Exec(ctx)
 	if err != nil {
-		return fmt.Errorf("redisclient - error updating leaderboard in Redis: %v", err)
+		return fmt.Errorf("redisclient - error updating player stats in Redis: %v", err)
+	}
+
+	return nil
+}
+
+// leaderboardCheckedKey is the key holding when the leaderboard of a game mode was last found unchanged upstream.
+func leaderboardCheckedKey(gameMode string) string {
+	return "leaderboard_checked:" + gameMode
+}
+
+// TouchLeaderboard records that the cached leaderboard of a game mode was found unchanged at checkedAt, extending
+// it and its players' stats by ttl instead of rewriting them. It returns ErrCacheMiss if the leaderboard is not
+// cached, and is fenced like UpdateLeaderboard.
+func (rc *RedisClient) TouchLeaderboard(ctx context.Context, gameMode string, leaderboardData *model.LeaderboardResponse, checkedAt time.Time, fence int64, ttl time.Duration) error {
+	extended, err := fencedExpireScript.Run(ctx, rc.Client, []string{leaderboardKey(gameMode), leaderboardFenceKey(gameMode)}, fence, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("redisclient - error extending leaderboard in Redis: %v", err)
+	}
+	switch extended {
+	case 0:
+		return ErrStaleFence
+	case -1:
+		return ErrCacheMiss
 	}
 
+	if ttl <= 0 {
+		return rc.Client.Set(ctx, leaderboardCheckedKey(gameMode), checkedAt.Format(time.RFC3339Nano), 0).Err()
+	}
+
+	pipe := rc.Client.Pipeline()
+	pipe.Set(ctx, leaderboardCheckedKey(gameMode), checkedAt.Format(time.RFC3339Nano), ttl)
+	for _, player := range leaderboardData.Included {
+		pipe.Expire(ctx, playerStatsKey(gameMode, player.ID), ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redisclient - error extending leaderboard in Redis: %v", err)
+	}
 	return nil
 }
 
-// GetPlayerStats retrieves a single player's stats from Redis.
-func (rc *RedisClient) GetPlayerStats(ctx context.Context, playerID string) (*model.PlayerAttribute, error) {
-	data, err := rc.Client.HGet(ctx, "player_stats:"+playerID, "stats").Result()
+// GetLeaderboardCheckedAt retrieves when the leaderboard of a game mode was last found unchanged upstream.
+func (rc *RedisClient) GetLeaderboardCheckedAt(ctx context.Context, gameMode string) (time.Time, error) {
+	data, err := rc.Client.Get(ctx, leaderboardCheckedKey(gameMode)).Result()
+	if err == redis.Nil {
+		return time.Time{}, ErrCacheMiss
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	checkedAt, err := time.Parse(time.RFC3339Nano, data)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("redisclient - error parsing leaderboard check time: %v", err)
+	}
+	return checkedAt, nil
+}
+
+// GetPlayerStats retrieves a single player's stats in a game mode from Redis.
+func (rc *RedisClient) GetPlayerStats(ctx context.Context, gameMode, playerID string) (*model.PlayerAttribute, error) {
+	data, err := rc.Client.HGet(ctx, playerStatsKey(gameMode, playerID), "stats").Result()
 	if err == redis.Nil {
 		return nil, ErrCacheMiss
 	} else if err != nil {
@@ -131,13 +267,265 @@ func (rc *RedisClient) GetSeason(ctx context.Context) (*model.SeasonData, error)
 	return season, nil
 }
 
-// UpdateSeason updates the current season data in Redis.
-func (rc *RedisClient) UpdateSeason(ctx context.Context, season *model.SeasonData) error {
+// UpdateSeason updates the current season data in Redis, expiring after ttl.
+func (rc *RedisClient) UpdateSeason(ctx context.Context, season *model.SeasonData, ttl time.Duration) error {
 	data, err := json.Marshal(season)
 	if err != nil {
 		return fmt.Errorf("redisclient - error marshalling season data: %v", err)
 	}
 
-	// This sets the season data with a 24-hour expiry, matching the daily season refresh requirement.
-	return rc.Client.Set(ctx, "current_season", data, 24*time.Hour).Err()
+	return rc.Client.Set(ctx, "current_season", data, ttl).Err()
+}
+
+// lastSeenSeasonKey holds the ID of the season the season job last saw. Unlike current_season it never
+// expires and only the season job writes it, so rollovers are noticed however long the job did not run.
+const lastSeenSeasonKey = "last_seen_season"
+
+// GetLastSeenSeason retrieves the ID of the season the season job last saw from Redis.
+func (rc *RedisClient) GetLastSeenSeason(ctx context.Context) (string, error) {
+	seasonID, err := rc.Client.Get(ctx, lastSeenSeasonKey).Result()
+	if err == redis.Nil {
+		return "", ErrCacheMiss
+	} else if err != nil {
+		return "", fmt.Errorf("redisclient - error getting last seen season from Redis: %v", err)
+	}
+	return seasonID, nil
+}
+
+// UpdateLastSeenSeason records the ID of the season the season job saw in Redis, without expiry.
+func (rc *RedisClient) UpdateLastSeenSeason(ctx context.Context, seasonID string) error {
+	if err := rc.Client.Set(ctx, lastSeenSeasonKey, seasonID, 0).Err(); err != nil {
+		return fmt.Errorf("redisclient - error updating last seen season in Redis: %v", err)
+	}
+	return nil
+}
+
+// GetSeasons retrieves the list of every season from Redis.
+func (rc *RedisClient) GetSeasons(ctx context.Context) ([]model.SeasonData, error) {
+	data, err := rc.Client.Get(ctx, "seasons").Result()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+
+	var seasons []model.SeasonData
+	err = json.Unmarshal([]byte(data), &seasons)
+	if err != nil {
+		return nil, fmt.Errorf("redisclient - error unmarshalling seasons: %v", err)
+	}
+
+	return seasons, nil
+}
+
+// UpdateSeasons stores the list of every season in Redis.
+func (rc *RedisClient) UpdateSeasons(ctx context.Context, seasons []model.SeasonData, ttl time.Duration) error {
+	data, err := json.Marshal(seasons)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling seasons: %v", err)
+	}
+
+	return rc.Client.Set(ctx, "seasons", data, ttl).Err()
+}
+
+// seasonLeaderboardKey is the key caching the leaderboard of a game mode in a past season.
+func seasonLeaderboardKey(seasonID, shardID, gameMode string) string {
+	return "season_leaderboard:" + seasonID + ":" + shardID + ":" + gameMode
+}
+
+// GetSeasonLeaderboard retrieves the cached leaderboard of a game mode in a past season from Redis.
+func (rc *RedisClient) GetSeasonLeaderboard(ctx context.Context, seasonID, shardID, gameMode string) (*model.LeaderboardResponse, error) {
+	data, err := rc.Client.Get(ctx, seasonLeaderboardKey(seasonID, shardID, gameMode)).Result()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+
+	leaderboard := &model.LeaderboardResponse{}
+	err = json.Unmarshal([]byte(data), leaderboard)
+	if err != nil {
+		return nil, fmt.Errorf("redisclient - error unmarshalling season leaderboard: %v", err)
+	}
+
+	return leaderboard, nil
+}
+
+// UpdateSeasonLeaderboard caches the leaderboard of a game mode in a past season in Redis.
+func (rc *RedisClient) UpdateSeasonLeaderboard(ctx context.Context, seasonID, shardID, gameMode string, leaderboard *model.LeaderboardResponse, ttl time.Duration) error {
+	data, err := json.Marshal(leaderboard)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling season leaderboard: %v", err)
+	}
+
+	return rc.Client.Set(ctx, seasonLeaderboardKey(seasonID, shardID, gameMode), data, ttl).Err()
+}
+
+// summaryHistoryTTL keeps the summary history of a season around well past the end of the season.
+const summaryHistoryTTL = 180 * 24 * time.Hour
+
+// summaryKey is the key holding the latest summary of a game mode's leaderboard.
+func summaryKey(gameMode string) string {
+	return "leaderboard_summary:" + gameMode
+}
+
+// summaryHistoryKey is the sorted set of summary points of a game mode in a season, scored by unix timestamp.
+func summaryHistoryKey(gameMode, seasonID string) string {
+	return "leaderboard_summary_history:" + gameMode + ":" + seasonID
+}
+
+// GetLeaderboardSummary retrieves the latest summary of a game mode's leaderboard from Redis.
+func (rc *RedisClient) GetLeaderboardSummary(ctx context.Context, gameMode string) (*model.LeaderboardSummary, error) {
+	data, err := rc.Client.Get(ctx, summaryKey(gameMode)).Result()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+
+	summary := &model.LeaderboardSummary{}
+	err = json.Unmarshal([]byte(data), summary)
+	if err != nil {
+		return nil, fmt.Errorf("redisclient - error unmarshalling leaderboard summary: %v", err)
+	}
+
+	return summary, nil
+}
+
+// UpdateLeaderboardSummary stores the latest summary of a leaderboard, expiring after ttl, and appends it to the
+// season's history.
+func (rc *RedisClient) UpdateLeaderboardSummary(ctx context.Context, summary *model.LeaderboardSummary, ttl time.Duration) error {
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling leaderboard summary: %v", err)
+	}
+
+	pointJSON, err := json.Marshal(model.SummaryPoint{
+		Timestamp:             summary.GeneratedAt,
+		Players:               summary.Players,
+		RankPointsPercentiles: summary.RankPointsPercentiles,
+		RankPointsThresholds:  summary.RankPointsThresholds,
+	})
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling summary point: %v", err)
+	}
+
+	historyKey := summaryHistoryKey(summary.GameMode, summary.SeasonID)
+
+	pipe := rc.Client.TxPipeline()
+	pipe.Set(ctx, summaryKey(summary.GameMode), summaryJSON, ttl)
+	pipe.ZAdd(ctx, historyKey, redis.Z{Score: float64(summary.GeneratedAt.Unix()), Member: pointJSON})
+	pipe.Expire(ctx, historyKey, summaryHistoryTTL)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("redisclient - error updating leaderboard summary in Redis: %v", err)
+	}
+
+	return nil
+}
+
+// ExtendLeaderboardSummary keeps the latest summary of a game mode's leaderboard for ttl more, as the leaderboard
+// it summarizes did not change. Summaries without a TTL are left as they are.
+func (rc *RedisClient) ExtendLeaderboardSummary(ctx context.Context, gameMode string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return rc.Client.Expire(ctx, summaryKey(gameMode), ttl).Err()
+}
+
+// GetSummaryHistory retrieves the summary points of a game mode in a season recorded between since and until.
+func (rc *RedisClient) GetSummaryHistory(ctx context.Context, gameMode, seasonID string, since, until time.Time) ([]model.SummaryPoint, error) {
+	members, err := rc.Client.ZRangeByScore(ctx, summaryHistoryKey(gameMode, seasonID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.Unix(), 10),
+		Max: strconv.FormatInt(until.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]model.SummaryPoint, 0, len(members))
+	for _, member := range members {
+		var point model.SummaryPoint
+		if err := json.Unmarshal([]byte(member), &point); err != nil {
+			return nil, fmt.Errorf("redisclient - error unmarshalling summary point: %v", err)
+		}
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// leaderboardUpdatesChannel is the pub/sub channel leaderboard diffs are fanned out on to every replica.
+const leaderboardUpdatesChannel = "leaderboard_updates"
+
+// PublishLeaderboardDiff announces a leaderboard diff to every subscribed replica.
+func (rc *RedisClient) PublishLeaderboardDiff(ctx context.Context, diff *model.LeaderboardDiff) error {
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling leaderboard diff: %v", err)
+	}
+
+	return rc.Client.Publish(ctx, leaderboardUpdatesChannel, data).Err()
+}
+
+// SubscribeLeaderboardDiffs calls handle for every leaderboard diff published by any replica, until ctx is done.
+func (rc *RedisClient) SubscribeLeaderboardDiffs(ctx context.Context, handle func(*model.LeaderboardDiff)) error {
+	pubsub := rc.Client.Subscribe(ctx, leaderboardUpdatesChannel)
+	defer pubsub.Close()
+
+	// Wait for the subscription to be confirmed so a broken connection is reported to the caller.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("redisclient - error subscribing to leaderboard updates: %v", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("redisclient - leaderboard updates subscription closed")
+			}
+			diff := &model.LeaderboardDiff{}
+			if err := json.Unmarshal([]byte(message.Payload), diff); err != nil {
+				continue
+			}
+			handle(diff)
+		}
+	}
+}
+
+// refreshReportKey is the key holding the report of the latest refresh of a game mode.
+func refreshReportKey(gameMode string) string {
+	return "refresh_report:" + gameMode
+}
+
+// GetRefreshReport retrieves the report of the latest refresh of a game mode from Redis.
+func (rc *RedisClient) GetRefreshReport(ctx context.Context, gameMode string) (*model.RefreshReport, error) {
+	data, err := rc.Client.Get(ctx, refreshReportKey(gameMode)).Result()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+
+	report := &model.RefreshReport{}
+	err = json.Unmarshal([]byte(data), report)
+	if err != nil {
+		return nil, fmt.Errorf("redisclient - error unmarshalling refresh report: %v", err)
+	}
+
+	return report, nil
+}
+
+// UpdateRefreshReport stores the report of the latest refresh of a game mode in Redis, expiring after ttl.
+func (rc *RedisClient) UpdateRefreshReport(ctx context.Context, report *model.RefreshReport, ttl time.Duration) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling refresh report: %v", err)
+	}
+
+	return rc.Client.Set(ctx, refreshReportKey(report.GameMode), data, ttl).Err()
 }
@@ -0,0 +1,80 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestLeaderboardWritesAreFenced(t *testing.T) {
+	const gameMode = "squad-fpp"
+	// The leaderboard key is the hash tag of its fence, keeping both in one cluster slot.
+	const fenceKey = "{leaderboard}:fence"
+
+	tests := []struct {
+		name      string
+		touch     bool
+		fence     int64
+		wantErr   error
+		wantFence string
+	}{
+		{name: "write with the stored token", fence: 3, wantFence: "3"},
+		{name: "write with a newer token", fence: 4, wantFence: "4"},
+		{name: "write with an older token", fence: 2, wantErr: store.ErrStaleFence, wantFence: "3"},
+		{name: "unfenced write", fence: 0, wantFence: "3"},
+		{name: "extend with a newer token", touch: true, fence: 4, wantFence: "4"},
+		{name: "extend with an older token", touch: true, fence: 2, wantErr: store.ErrStaleFence, wantFence: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server, rc := testutil.NewRedis(t)
+
+			if err := rc.UpdateLeaderboard(ctx, gameMode, testutil.Leaderboard(gameMode, "a", "b"), 3, time.Minute); err != nil {
+				t.Fatalf("UpdateLeaderboard: %v", err)
+			}
+
+			next := testutil.Leaderboard(gameMode, "b", "a")
+			var err error
+			if tt.touch {
+				err = rc.TouchLeaderboard(ctx, gameMode, next, time.Now(), tt.fence, time.Hour)
+			} else {
+				err = rc.UpdateLeaderboard(ctx, gameMode, next, tt.fence, time.Hour)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if got, _ := server.Get(fenceKey); got != tt.wantFence {
+				t.Errorf("fence %q, want %q", got, tt.wantFence)
+			}
+
+			// Rejected writes leave the leaderboard and its expiry as they were.
+			applied := tt.wantErr == nil
+			leaderboard, err := rc.GetLeaderboard(ctx, gameMode)
+			if err != nil {
+				t.Fatalf("GetLeaderboard: %v", err)
+			}
+			if rewritten := leaderboard.Included[0].ID == "b"; rewritten != (applied && !tt.touch) {
+				t.Errorf("leaderboard rewritten: %t", rewritten)
+			}
+			if extended := server.TTL("leaderboard") == time.Hour; extended != applied {
+				t.Errorf("leaderboard extended: %t", extended)
+			}
+		})
+	}
+}
+
+func TestTouchLeaderboardMissing(t *testing.T) {
+	_, rc := testutil.NewRedis(t)
+
+	err := rc.TouchLeaderboard(context.Background(), "squad-fpp", testutil.Leaderboard("squad-fpp", "a"), time.Now(), 1, time.Hour)
+	if !errors.Is(err, store.ErrCacheMiss) {
+		t.Errorf("got error %v, want %v", err, store.ErrCacheMiss)
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingHook traces every Redis command and pipeline in a client span named after the command.
+type tracingHook struct{}
+
+// DialHook leaves connections untraced.
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook traces a single command.
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := tracing.Start(ctx, "redis "+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperation(cmd.Name())))
+
+		err := next(ctx, cmd)
+		tracing.End(span, spanError(err))
+		return err
+	}
+}
+
+// ProcessPipelineHook traces a pipeline or transaction as a whole.
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := tracing.Start(ctx, "redis pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, attribute.Int("db.redis.pipeline_length", len(cmds))))
+
+		err := next(ctx, cmds)
+		tracing.End(span, spanError(err))
+		return err
+	}
+}
+
+// spanError returns the error a command span is marked failed with. A missing key is not a failure, and neither
+// is a script missing from the script cache, which is loaded and run again.
+func spanError(err error) error {
+	if err == redis.Nil || redis.HasErrorPrefix(err, "NOSCRIPT") {
+		return nil
+	}
+	return err
+}
+
+// tracingTransport wraps the transport of the MinIO client so every S3 request is traced in a client span.
+func tracingTransport(transport http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(transport,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "minio " + r.Method
+		}),
+		otelhttp.WithSpanOptions(trace.WithAttributes(attribute.String("peer.service", "minio"))),
+	)
+}
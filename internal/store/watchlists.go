@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// watchlistsKey is the hash of watchlists keyed by watchlist ID.
+	watchlistsKey = "watchlists"
+	// rankHistoryRetention is how long the rank points of watched players are kept.
+	rankHistoryRetention = 180 * 24 * time.Hour
+)
+
+// rankHistoryKey is the sorted set of a player's rank points in a game mode and season, scored by unix timestamp.
+// Ranks restart with every season, so each season has its own history.
+func rankHistoryKey(gameMode, seasonID, playerID string) string {
+	return "player_rank_history:" + gameMode + ":" + seasonID + ":" + playerID
+}
+
+// SaveWatchlist creates or replaces a watchlist.
+func (rc *RedisClient) SaveWatchlist(ctx context.Context, watchlist *model.Watchlist) error {
+	data, err := json.Marshal(watchlist)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling watchlist: %v", err)
+	}
+
+	return rc.Client.HSet(ctx, watchlistsKey, watchlist.ID, data).Err()
+}
+
+// GetWatchlist retrieves a watchlist, returning ErrCacheMiss if it does not exist.
+func (rc *RedisClient) GetWatchlist(ctx context.Context, watchlistID string) (*model.Watchlist, error) {
+	data, err := rc.Client.HGet(ctx, watchlistsKey, watchlistID).Result()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+
+	watchlist := &model.Watchlist{}
+	err = json.Unmarshal([]byte(data), watchlist)
+	if err != nil {
+		return nil, fmt.Errorf("redisclient - error unmarshalling watchlist: %v", err)
+	}
+
+	return watchlist, nil
+}
+
+// ListWatchlists retrieves every watchlist ordered by creation time.
+func (rc *RedisClient) ListWatchlists(ctx context.Context) ([]*model.Watchlist, error) {
+	entries, err := rc.Client.HGetAll(ctx, watchlistsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	watchlists := make([]*model.Watchlist, 0, len(entries))
+	for _, data := range entries {
+		watchlist := &model.Watchlist{}
+		if err := json.Unmarshal([]byte(data), watchlist); err != nil {
+			return nil, fmt.Errorf("redisclient - error unmarshalling watchlist: %v", err)
+		}
+		watchlists = append(watchlists, watchlist)
+	}
+	sort.Slice(watchlists, func(i, j int) bool { return watchlists[i].CreatedAt.Before(watchlists[j].CreatedAt) })
+
+	return watchlists, nil
+}
+
+// DeleteWatchlist removes a watchlist, returning ErrCacheMiss if it does not exist. The rank history of its
+// players is kept, since other watchlists may share them.
+func (rc *RedisClient) DeleteWatchlist(ctx context.Context, watchlistID string) error {
+	deleted, err := rc.Client.HDel(ctx, watchlistsKey, watchlistID).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrCacheMiss
+	}
+
+	return nil
+}
+
+// AddRankPoints appends a rank point per player to their rank history in a game mode and season and drops
+// points older than the retention period.
+func (rc *RedisClient) AddRankPoints(ctx context.Context, gameMode, seasonID string, points map[string]model.RankPoint) error {
+	cutoff := strconv.FormatInt(time.Now().Add(-rankHistoryRetention).Unix(), 10)
+
+	pipe := rc.Client.TxPipeline()
+	for playerID, point := range points {
+		pointJSON, err := json.Marshal(point)
+		if err != nil {
+			return fmt.Errorf("redisclient - error marshalling rank point: %v", err)
+		}
+
+		key := rankHistoryKey(gameMode, seasonID, playerID)
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(point.Timestamp.Unix()), Member: pointJSON})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff)
+		pipe.Expire(ctx, key, rankHistoryRetention)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("redisclient - error updating rank history in Redis: %v", err)
+	}
+
+	return nil
+}
+
+// RankPointsQuery asks for up to Count of a player's most recent rank points recorded at or before Before.
+type RankPointsQuery struct {
+	PlayerID string
+	Before   time.Time
+	Count    int64
+}
+
+// GetRecentRankPoints runs rank history queries of players in a game mode and season in a single pipeline,
+// returning the rank points of each query newest first, in the order of the queries.
+func (rc *RedisClient) GetRecentRankPoints(ctx context.Context, gameMode, seasonID string, queries []RankPointsQuery) ([][]model.RankPoint, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	pipe := rc.Client.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(queries))
+	for i, query := range queries {
+		cmds[i] = pipe.ZRevRangeByScore(ctx, rankHistoryKey(gameMode, seasonID, query.PlayerID), &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(query.Before.Unix(), 10),
+			Count: query.Count,
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	results := make([][]model.RankPoint, len(queries))
+	for i, cmd := range cmds {
+		points := make([]model.RankPoint, 0, len(cmd.Val()))
+		for _, member := range cmd.Val() {
+			var point model.RankPoint
+			if err := json.Unmarshal([]byte(member), &point); err != nil {
+				return nil, fmt.Errorf("redisclient - error unmarshalling rank point: %v", err)
+			}
+			points = append(points, point)
+		}
+		results[i] = points
+	}
+
+	return results, nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// webhooksKey is the hash of webhook subscriptions keyed by subscription ID.
+	webhooksKey = "webhooks"
+	// maxWebhookDeliveries is how many deliveries are kept in each subscription's history.
+	maxWebhookDeliveries = 100
+)
+
+// webhookDeliveriesKey is the list of a subscription's most recent deliveries, newest first.
+func webhookDeliveriesKey(webhookID string) string {
+	return "webhook_deliveries:" + webhookID
+}
+
+// SaveWebhook creates or replaces a webhook subscription.
+func (rc *RedisClient) SaveWebhook(ctx context.Context, webhook *model.WebhookSubscription) error {
+	data, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling webhook: %v", err)
+	}
+
+	return rc.Client.HSet(ctx, webhooksKey, webhook.ID, data).Err()
+}
+
+// GetWebhook retrieves a webhook subscription, returning ErrCacheMiss if it does not exist.
+func (rc *RedisClient) GetWebhook(ctx context.Context, webhookID string) (*model.WebhookSubscription, error) {
+	data, err := rc.Client.HGet(ctx, webhooksKey, webhookID).Result()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+
+	webhook := &model.WebhookSubscription{}
+	err = json.Unmarshal([]byte(data), webhook)
+	if err != nil {
+		return nil, fmt.Errorf("redisclient - error unmarshalling webhook: %v", err)
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks retrieves every webhook subscription ordered by creation time.
+func (rc *RedisClient) ListWebhooks(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	entries, err := rc.Client.HGetAll(ctx, webhooksKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]*model.WebhookSubscription, 0, len(entries))
+	for _, data := range entries {
+		webhook := &model.WebhookSubscription{}
+		if err := json.Unmarshal([]byte(data), webhook); err != nil {
+			return nil, fmt.Errorf("redisclient - error unmarshalling webhook: %v", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook subscription and its delivery history, returning ErrCacheMiss if it does not exist.
+func (rc *RedisClient) DeleteWebhook(ctx context.Context, webhookID string) error {
+	deleted, err := rc.Client.HDel(ctx, webhooksKey, webhookID).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrCacheMiss
+	}
+
+	return rc.Client.Del(ctx, webhookDeliveriesKey(webhookID)).Err()
+}
+
+// AddWebhookDelivery records a delivery in the subscription's history, keeping only the most recent ones.
+func (rc *RedisClient) AddWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("redisclient - error marshalling webhook delivery: %v", err)
+	}
+
+	key := webhookDeliveriesKey(delivery.WebhookID)
+	pipe := rc.Client.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, maxWebhookDeliveries-1)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("redisclient - error recording webhook delivery in Redis: %v", err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries retrieves a subscription's recent deliveries, newest first.
+func (rc *RedisClient) ListWebhookDeliveries(ctx context.Context, webhookID string) ([]model.WebhookDelivery, error) {
+	entries, err := rc.Client.LRange(ctx, webhookDeliveriesKey(webhookID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(entries))
+	for _, data := range entries {
+		var delivery model.WebhookDelivery
+		if err := json.Unmarshal([]byte(data), &delivery); err != nil {
+			return nil, fmt.Errorf("redisclient - error unmarshalling webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,180 @@
+package testutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// FakeMinio serves, from memory, the part of the S3 API the service uses: bucket locations, listing objects by
+// prefix, and getting, stating and putting objects. Objects are kept by "<bucket>/<key>".
+type FakeMinio struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewFakeMinio starts a fake MinIO closed when the test ends.
+func NewFakeMinio(t testing.TB) *FakeMinio {
+	t.Helper()
+
+	fake := &FakeMinio{objects: make(map[string][]byte)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+// Endpoint returns the host and port to configure as MINIO_ENDPOINT.
+func (f *FakeMinio) Endpoint() string {
+	return strings.TrimPrefix(f.URL, "http://")
+}
+
+// Put stores an object.
+func (f *FakeMinio) Put(bucket, key string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[bucket+"/"+key] = data
+}
+
+// Get returns an object and whether it exists.
+func (f *FakeMinio) Get(bucket, key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[bucket+"/"+key]
+	return data, ok
+}
+
+// listBucketResult is the response of ListObjectsV2.
+type listBucketResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	Name        string
+	Prefix      string
+	KeyCount    int
+	MaxKeys     int
+	IsTruncated bool
+	Contents    []listedObject
+}
+
+type listedObject struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+}
+
+func (f *FakeMinio) serve(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	switch {
+	case key == "" && query.Has("location"):
+		writeXML(w, http.StatusOK, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+		}{})
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, bucket, query.Get("prefix"))
+	case r.Method == http.MethodPut:
+		data, err := readPayload(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.Put(bucket, key, data)
+		w.Header().Set("ETag", `"fake"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.Get(bucket, key)
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeXML(w, http.StatusNotFound, struct {
+				XMLName    xml.Name `xml:"Error"`
+				Code       string
+				Key        string
+				BucketName string
+			}{Code: "NoSuchKey", Key: key, BucketName: bucket})
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"fake"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	default:
+		http.Error(w, "not implemented by the fake", http.StatusNotImplemented)
+	}
+}
+
+// list answers ListObjectsV2 with every object of the bucket under prefix, in key order.
+func (f *FakeMinio) list(w http.ResponseWriter, bucket, prefix string) {
+	f.mu.Lock()
+	result := listBucketResult{Name: bucket, Prefix: prefix, MaxKeys: 1000}
+	for name, data := range f.objects {
+		if key, ok := strings.CutPrefix(name, bucket+"/"); ok && strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, listedObject{
+				Key:          key,
+				LastModified: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+				ETag:         `"fake"`,
+				Size:         len(data),
+			})
+		}
+	}
+	f.mu.Unlock()
+
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+	writeXML(w, http.StatusOK, result)
+}
+
+// readPayload reads the body of a put, decoding the chunks of streaming signed uploads.
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	body := bufio.NewReader(r.Body)
+	for {
+		header, err := body.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("testutil - reading chunk header: %v", err)
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("testutil - invalid chunk size %q: %v", sizeHex, err)
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, body, size); err != nil {
+			return nil, fmt.Errorf("testutil - reading chunk: %v", err)
+		}
+		if _, err := body.Discard(2); err != nil {
+			return nil, fmt.Errorf("testutil - reading chunk trailer: %v", err)
+		}
+	}
+}
+
+func writeXML(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,219 @@
+// Package testutil provides the fakes the tests of the service run against: an in-memory Redis, a fake PUBG API,
+// a fake MinIO and a configuration pointing at them.
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gbasileGP/pubg-leaderboard/internal/config"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/sirupsen/logrus"
+)
+
+// Season is the current season served by FakePUBG.
+const Season = "division.bro.official.pc-2018-30"
+
+// NewRedis starts an in-memory Redis and connects a client to it, both closed when the test ends.
+func NewRedis(t testing.TB) (*miniredis.Miniredis, *store.RedisClient) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	redisClient, err := store.NewRedisClient([]string{server.Addr()}, "", 0)
+	if err != nil {
+		t.Fatalf("testutil - failed to connect to miniredis: %v", err)
+	}
+	t.Cleanup(func() { _ = redisClient.Client.Close() })
+
+	return server, redisClient
+}
+
+// Logger returns a logger discarding everything, so tests only print their own failures.
+func Logger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// Config loads the configuration from settings, written KEY=value, set as environment variables for the test on
+// top of defaults pointing the service at the PUBG API served at pubgURL, with every scheduled job disabled so tests
+// run them explicitly and webhooks allowed to reach the local test servers.
+func Config(t testing.TB, pubgURL string, settings ...string) *config.Config {
+	t.Helper()
+
+	defaults := []string{
+		"PUBG_API_KEY=test",
+		"PUBG_API_ENDPOINT=" + pubgURL + "/shards/pc-na",
+		"MINIO_ENDPOINT=127.0.0.1:1",
+		`REFRESH_SCHEDULES={"season":{"runOnStart":false,"schedule":"1h"},"leaderboard":{"runOnStart":false,"schedule":"1h"}}`,
+		"REFRESH_LEASES=false",
+		"WEBHOOK_BACKOFF=10ms",
+		"WEBHOOK_ALLOW_PRIVATE=true",
+	}
+	for _, setting := range append(defaults, settings...) {
+		key, value, _ := strings.Cut(setting, "=")
+		t.Setenv(key, value)
+	}
+
+	cfg, err := config.LoadConfig(nil)
+	if err != nil {
+		t.Fatalf("testutil - invalid test configuration: %v", err)
+	}
+	return cfg
+}
+
+// Leaderboard builds a leaderboard of a game mode in the current season with one player per ID, ranked in order.
+func Leaderboard(gameMode string, playerIDs ...string) *model.LeaderboardResponse {
+	leaderboard := &model.LeaderboardResponse{}
+	leaderboard.Data.Type = "leaderboard"
+	leaderboard.Data.ID = gameMode
+	leaderboard.Data.Attributes = model.LeaderboardAttribute{ShardId: "pc-na", GameMode: gameMode, SeasonId: Season}
+
+	for i, playerID := range playerIDs {
+		leaderboard.Included = append(leaderboard.Included, model.PlayerData{
+			Type: "player",
+			ID:   playerID,
+			Attributes: model.PlayerAttribute{
+				Name: "player-" + playerID,
+				Rank: i + 1,
+				Stats: model.PlayerStats{
+					RankPoints: float64(5000 - 10*i),
+					Games:      50,
+					Wins:       5,
+					Kills:      100,
+					Kda:        2,
+					Tier:       "Master",
+					SubTier:    "1",
+				},
+			},
+		})
+		leaderboard.Data.Relationships.Players.Data = append(leaderboard.Data.Relationships.Players.Data, model.PlayerDataReference{Type: "player", ID: playerID})
+	}
+
+	return leaderboard
+}
+
+// FakePUBG serves the seasons, leaderboards and status endpoints of the PUBG API. Leaderboards carry an ETag
+// that changes with SetLeaderboard, and are answered 304 Not Modified when revalidated with the current one.
+// SetHang leaves leaderboard requests unanswered until their client gives up, SetStatus answers them with an
+// error.
+type FakePUBG struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	leaderboards map[string]*model.LeaderboardResponse
+	versions     map[string]int // Incremented by SetLeaderboard, the ETag of a game mode's leaderboard
+	hang         bool
+	status       int
+	requests     int
+	cancelled    chan struct{}
+}
+
+// NewFakePUBG starts a fake PUBG API serving the leaderboards, by game mode, closed when the test ends.
+func NewFakePUBG(t testing.TB, leaderboards ...*model.LeaderboardResponse) *FakePUBG {
+	t.Helper()
+
+	fake := &FakePUBG{
+		leaderboards: make(map[string]*model.LeaderboardResponse),
+		versions:     make(map[string]int),
+		cancelled:    make(chan struct{}, 64),
+	}
+	for _, leaderboard := range leaderboards {
+		fake.leaderboards[leaderboard.Data.Attributes.GameMode] = leaderboard
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+// SetHang makes leaderboard requests hang, never answered, or answered again.
+func (f *FakePUBG) SetHang(hang bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hang = hang
+}
+
+// SetStatus makes leaderboard requests fail with the status code, or served again with 0.
+func (f *FakePUBG) SetStatus(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+}
+
+// SetLeaderboard replaces the leaderboard served for its game mode.
+func (f *FakePUBG) SetLeaderboard(leaderboard *model.LeaderboardResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.leaderboards[leaderboard.Data.Attributes.GameMode] = leaderboard
+	f.versions[leaderboard.Data.Attributes.GameMode]++
+}
+
+// Requests returns how many leaderboard requests were received.
+func (f *FakePUBG) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+// Cancelled is signalled every time a hanging request is abandoned by its client.
+func (f *FakePUBG) Cancelled() <-chan struct{} {
+	return f.cancelled
+}
+
+func (f *FakePUBG) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/status":
+		w.WriteHeader(http.StatusOK)
+		return
+	case strings.HasSuffix(r.URL.Path, "/seasons"):
+		writeJSON(w, model.SeasonsResponse{Data: []model.SeasonData{
+			{Type: "season", ID: Season, Attributes: model.SeasonAttribute{IsCurrentSeason: true}},
+		}})
+		return
+	}
+
+	f.mu.Lock()
+	f.requests++
+	hang, status := f.hang, f.status
+	gameMode := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	leaderboard, found := f.leaderboards[gameMode]
+	etag := fmt.Sprintf(`"%s-%d"`, gameMode, f.versions[gameMode])
+	f.mu.Unlock()
+
+	if hang {
+		<-r.Context().Done()
+		select {
+		case f.cancelled <- struct{}{}:
+		default:
+		}
+		return
+	}
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	writeJSON(w, leaderboard)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
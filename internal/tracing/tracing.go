@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in traces unless OTEL_SERVICE_NAME says otherwise.
+const ServiceName = "pubg-leaderboard"
+
+// instrumentationName names the tracer of the application's own spans.
+const instrumentationName = "github.com/gbasileGP/pubg-leaderboard"
+
+// Exporters accepted by NewProvider.
+const (
+	ExporterNone   = "none"   // Spans are created for trace IDs in logs but not exported
+	ExporterStdout = "stdout" // Spans are written to stdout, for local testing
+	ExporterOTLP   = "otlp"   // Spans are sent over OTLP/HTTP to OTEL_EXPORTER_OTLP_ENDPOINT
+)
+
+// Provider is the tracer provider of the application, installed globally. It is a lifecycle service so spans
+// still buffered are exported on shutdown.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+}
+
+// NewProvider creates the tracer provider exporting spans with the named exporter, sampling sampleRatio of the
+// traces started by the service. Traces started upstream follow the caller's sampling decision.
+func NewProvider(ctx context.Context, exporter string, sampleRatio float64) (*Provider, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing - failed to describe the service: %w", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	}
+
+	switch exporter {
+	case ExporterNone:
+	case ExporterStdout:
+		spanExporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("tracing - failed to create stdout exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(spanExporter))
+	case ExporterOTLP:
+		spanExporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("tracing - failed to create OTLP exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(spanExporter))
+	default:
+		return nil, fmt.Errorf("tracing - unknown exporter %q", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return &Provider{provider: provider}, nil
+}
+
+// Start does nothing, the provider is ready once created.
+func (p *Provider) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop exports the spans still buffered and shuts the provider down.
+func (p *Provider) Stop(ctx context.Context) error {
+	return p.provider.Shutdown(ctx)
+}
+
+// Start starts a span of the application as a child of the span in ctx, if any.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+// End ends a span, marking it failed with err if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// LogHook adds the trace and span IDs of the span in an entry's context to the entry, so logs written with
+// logger.WithContext(ctx) can be matched to traces.
+type LogHook struct{}
+
+// Levels returns every level, IDs are added to all entries.
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire adds the trace_id and span_id fields to entries logged within a span.
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	entry.Data["trace_id"] = spanContext.TraceID().String()
+	entry.Data["span_id"] = spanContext.SpanID().String()
+	return nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// Limits of the chat services on the length of announcement texts.
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordFieldNameLimit   = 256
+	discordFieldValueLimit  = 1024
+	discordFooterLimit      = 2048
+	slackHeaderLimit        = 150
+	slackTextLimit          = 3000
+)
+
+// discordEmbedColor is the accent color of Discord embeds, PUBG yellow.
+const discordEmbedColor = 0xF2A900
+
+// Announcement is a chat message independent of the service it is posted to.
+type Announcement struct {
+	Title       string
+	Description string
+	Fields      []AnnouncementField
+	Footer      string
+	Timestamp   time.Time
+}
+
+// AnnouncementField is a titled section of an announcement.
+type AnnouncementField struct {
+	Name  string
+	Value string
+}
+
+// DiscordPayload renders the announcement as a Discord incoming webhook body with a single embed.
+func (a *Announcement) DiscordPayload() map[string]interface{} {
+	embed := map[string]interface{}{
+		"color":     discordEmbedColor,
+		"timestamp": a.Timestamp.UTC().Format(time.RFC3339),
+	}
+	if a.Title != "" {
+		embed["title"] = truncate(a.Title, discordTitleLimit)
+	}
+	if a.Description != "" {
+		embed["description"] = truncate(a.Description, discordDescriptionLimit)
+	}
+	if a.Footer != "" {
+		embed["footer"] = map[string]string{"text": truncate(a.Footer, discordFooterLimit)}
+	}
+
+	fields := make([]map[string]interface{}, 0, len(a.Fields))
+	for _, field := range a.Fields {
+		fields = append(fields, map[string]interface{}{
+			"name":   truncate(field.Name, discordFieldNameLimit),
+			"value":  truncate(field.Value, discordFieldValueLimit),
+			"inline": false,
+		})
+	}
+	if len(fields) > 0 {
+		embed["fields"] = fields
+	}
+
+	return map[string]interface{}{"embeds": []interface{}{embed}}
+}
+
+// SlackPayload renders the announcement as a Slack incoming webhook body using Block Kit, with a plain
+// text fallback for notifications.
+func (a *Announcement) SlackPayload() map[string]interface{} {
+	var blocks []map[string]interface{}
+	if a.Title != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": truncate(a.Title, slackHeaderLimit)},
+		})
+	}
+	if a.Description != "" {
+		blocks = append(blocks, slackSection(a.Description))
+	}
+	for _, field := range a.Fields {
+		blocks = append(blocks, slackSection("*"+field.Name+"*\n"+field.Value))
+	}
+	if a.Footer != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []interface{}{map[string]interface{}{"type": "mrkdwn", "text": truncate(a.Footer, slackTextLimit)}},
+		})
+	}
+
+	fallback := a.Title
+	if fallback == "" {
+		fallback = a.Description
+	}
+
+	return map[string]interface{}{
+		"text":   truncate(fallback, slackTextLimit),
+		"blocks": blocks,
+	}
+}
+
+// slackSection is a Block Kit section block holding markdown text.
+func slackSection(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "section",
+		"text": map[string]interface{}{"type": "mrkdwn", "text": truncate(text, slackTextLimit)},
+	}
+}
+
+// truncate shortens text to at most limit characters, marking the cut with an ellipsis.
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
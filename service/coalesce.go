@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+)
+
+// ErrFetchTimeout is returned when data missing from the cache is still being fetched after the bounded wait.
+var ErrFetchTimeout = errors.New("timed out waiting for data to be fetched")
+
+// fetchPollInterval is how often a replica waiting for another one to fetch data checks the cache.
+const fetchPollInterval = 100 * time.Millisecond
+
+// fetchLockReleaseTimeout bounds the release of a fetch lock, which runs even when the fetch was cancelled.
+const fetchLockReleaseTimeout = 2 * time.Second
+
+// sharedFetch is a fetch shared by the callers waiting for the same cache key, with how many of them still wait
+// and, once done is closed, its result.
+type sharedFetch struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+	// detached is set once a caller gave up waiting, so the fetch carries on to fill the cache for the next
+	// request even when no caller is left.
+	detached bool
+
+	done chan struct{} // Closed once the fetch returned
+	val  interface{}
+	err  error
+}
+
+// coalesceFetch returns the data of a cache key after a cache miss, making sure a single fetch runs for the key
+// across the deployment. Concurrent callers in this replica share one fetch, and replicas take a Redis lock on
+// the key so that only one of them calls the PUBG API while the others wait for the data to reach the cache.
+// Callers give up with ErrFetchTimeout after the configured wait, the fetch itself carries on in the
+// background so the cache is filled for the next request. A fetch whose callers all went away, such as clients
+// that disconnected, is cancelled.
+func coalesceFetch[T any](ctx context.Context, ls *LeaderboardService, key string, cached, fetch func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+
+	// The shared fetch must not be cancelled because the caller that started it went away, only once every
+	// caller did.
+	shared := ls.joinFetch(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return fetchLocked(ctx, ls, key, cached, fetch)
+	})
+
+	timer := time.NewTimer(ls.fetchWait)
+	defer timer.Stop()
+
+	select {
+	case <-shared.done:
+		ls.leaveFetch(key, shared, false)
+		if shared.err != nil {
+			return zero, shared.err
+		}
+		return shared.val.(T), nil
+	case <-timer.C:
+		ls.leaveFetch(key, shared, true)
+		ls.log(ctx).WithField("key", key).Warn("svc: coalesceFetch - Gave up waiting for fetch")
+		return zero, fmt.Errorf("svc: coalesceFetch - %s: %w", key, ErrFetchTimeout)
+	case <-ctx.Done():
+		ls.leaveFetch(key, shared, false)
+		return zero, ctx.Err()
+	}
+}
+
+// joinFetch registers a caller waiting for the fetch of a cache key, returning the fetch it shares with the
+// other callers. The first caller starts the fetch with run, so a shared fetch and the work it waits for are
+// always the same.
+func (ls *LeaderboardService) joinFetch(ctx context.Context, key string, run func(ctx context.Context) (interface{}, error)) *sharedFetch {
+	ls.fetchWaitMu.Lock()
+	defer ls.fetchWaitMu.Unlock()
+
+	shared, ok := ls.fetchWaiters[key]
+	if !ok {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		shared = &sharedFetch{ctx: fetchCtx, cancel: cancel, done: make(chan struct{})}
+		ls.fetchWaiters[key] = shared
+		go func() {
+			defer close(shared.done)
+			defer ls.endFetch(key, shared)
+			shared.val, shared.err = run(shared.ctx)
+		}()
+	}
+	shared.waiters++
+	return shared
+}
+
+// leaveFetch unregisters a caller of a shared fetch, cancelling the fetch if it was the last one and none gave
+// up waiting. A cancelled fetch is unregistered so later callers start their own.
+func (ls *LeaderboardService) leaveFetch(key string, shared *sharedFetch, gaveUp bool) {
+	ls.fetchWaitMu.Lock()
+	defer ls.fetchWaitMu.Unlock()
+
+	shared.waiters--
+	shared.detached = shared.detached || gaveUp
+	if shared.waiters > 0 || shared.detached {
+		return
+	}
+	shared.cancel()
+	if ls.fetchWaiters[key] == shared {
+		delete(ls.fetchWaiters, key)
+	}
+}
+
+// endFetch unregisters a shared fetch once it returned, so the next cache miss starts a new one, and releases
+// its context.
+func (ls *LeaderboardService) endFetch(key string, shared *sharedFetch) {
+	ls.fetchWaitMu.Lock()
+	defer ls.fetchWaitMu.Unlock()
+
+	shared.cancel()
+	if ls.fetchWaiters[key] == shared {
+		delete(ls.fetchWaiters, key)
+	}
+}
+
+// fetchLocked fetches the data of a cache key while holding its fetch lock, or waits for the replica holding
+// the lock to cache it. The fetch runs for at most the lock's duration so the lock never expires during it.
+func fetchLocked[T any](ctx context.Context, ls *LeaderboardService, key string, cached, fetch func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	logger := ls.log(ctx).WithField("key", key)
+	deadline := time.Now().Add(ls.fetchWait)
+
+	for {
+		acquired, err := ls.redisClient.AcquireFetchLock(ctx, key, ls.replicaID, ls.fetchLockTTL)
+		if err != nil && ctx.Err() != nil {
+			return zero, ctx.Err()
+		} else if err != nil {
+			// Without Redis there is no cache to coordinate through, this replica fetches on its own.
+			logger.WithError(err).Warn("svc: fetchLocked - Failed to acquire fetch lock, fetching without it")
+			fetchCtx, cancel := context.WithTimeout(ctx, ls.fetchLockTTL)
+			defer cancel()
+			return fetch(fetchCtx)
+		}
+
+		if acquired {
+			defer func() {
+				// The lock is released even when the fetch was cancelled, so other replicas need not wait for it
+				// to expire.
+				releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchLockReleaseTimeout)
+				defer cancel()
+				if err := ls.redisClient.ReleaseFetchLock(releaseCtx, key, ls.replicaID); err != nil {
+					logger.WithError(err).Warn("svc: fetchLocked - Failed to release fetch lock")
+				}
+			}()
+
+			// Another replica may have filled the cache between the miss and the lock.
+			if data, err := cached(ctx); err == nil {
+				return data, nil
+			}
+
+			fetchCtx, cancel := context.WithTimeout(ctx, ls.fetchLockTTL)
+			defer cancel()
+			return fetch(fetchCtx)
+		}
+
+		if time.Now().After(deadline) {
+			return zero, fmt.Errorf("svc: fetchLocked - %s is being fetched by another replica: %w", key, ErrFetchTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-time.After(fetchPollInterval):
+		}
+
+		data, err := cached(ctx)
+		if err == nil {
+			logger.Info("svc: fetchLocked - Data fetched by another replica")
+			return data, nil
+		} else if !errors.Is(err, store.ErrCacheMiss) {
+			return zero, err
+		}
+	}
+}
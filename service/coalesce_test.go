@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestFetchLocked(t *testing.T) {
+	const key = "leaderboard:squad-fpp"
+
+	tests := []struct {
+		name           string
+		lockedByOther  bool // Another replica holds the fetch lock
+		redisDown      bool
+		cancelBefore   bool // The caller went away before fetchLocked started
+		cancelInFetch  bool // The caller goes away during the fetch
+		deadline       time.Duration
+		wantErr        error
+		wantFetch      bool
+		wantLockedHeld bool
+	}{
+		{name: "fetches under the lock and releases it", wantFetch: true},
+		{name: "releases the lock after a cancelled fetch", cancelInFetch: true, wantErr: context.Canceled, wantFetch: true},
+		{name: "stops waiting for another replica when cancelled", lockedByOther: true, deadline: 3 * fetchPollInterval, wantErr: context.DeadlineExceeded, wantLockedHeld: true},
+		{name: "does not fetch without the lock when cancelled", cancelBefore: true, wantErr: context.Canceled},
+		{name: "fetches without the lock when Redis fails", redisDown: true, wantFetch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pubg := testutil.NewFakePUBG(t)
+			ls, _ := newTestService(t, pubg)
+			server, redisClient := testutil.NewRedis(t)
+			ls.redisClient = redisClient
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.deadline > 0 {
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+			if tt.lockedByOther {
+				if _, err := redisClient.AcquireFetchLock(ctx, key, "other-replica", time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.redisDown {
+				server.Close()
+			}
+			if tt.cancelBefore {
+				cancel()
+			}
+
+			fetched := false
+			fetch := func(ctx context.Context) (string, error) {
+				fetched = true
+				if tt.cancelInFetch {
+					cancel()
+					return "", ctx.Err()
+				}
+				return "fetched", nil
+			}
+			cached := func(ctx context.Context) (string, error) { return "", store.ErrCacheMiss }
+
+			start := time.Now()
+			data, err := fetchLocked(ctx, ls, key, cached, fetch)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) || tt.wantErr == nil && (err != nil || data != "fetched") {
+				t.Errorf("fetchLocked returned %q, %v, want %v", data, err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > ls.fetchWait/2 {
+				t.Errorf("fetchLocked took %v, want it to return as soon as the caller went away", elapsed)
+			}
+			if fetched != tt.wantFetch {
+				t.Errorf("fetched = %t, want %t", fetched, tt.wantFetch)
+			}
+			if !tt.redisDown {
+				if held := server.Exists("fetch_lock:" + key); held != tt.wantLockedHeld {
+					t.Errorf("fetch lock held = %t, want %t", held, tt.wantLockedHeld)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelledFetchIsNotSharedWithLaterCallers(t *testing.T) {
+	const key = "leaderboard:squad-fpp"
+
+	pubg := testutil.NewFakePUBG(t)
+	ls, _ := newTestService(t, pubg)
+
+	// Fetches ignore cancellation until told to finish, like a PUBG call that is slow to notice it.
+	var fetches atomic.Int32
+	finish := []chan struct{}{make(chan struct{}), make(chan struct{})}
+	cached := func(ctx context.Context) (string, error) { return "", store.ErrCacheMiss }
+	fetch := func(ctx context.Context) (string, error) {
+		n := fetches.Add(1)
+		<-finish[n-1]
+		return fmt.Sprintf("fetch %d", n), nil
+	}
+	waitFor := func(n int32) {
+		for fetches.Load() < n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	registered := func() *sharedFetch {
+		ls.fetchWaitMu.Lock()
+		defer ls.fetchWaitMu.Unlock()
+		return ls.fetchWaiters[key]
+	}
+
+	// The only caller of the first fetch goes away, cancelling it while it is still running.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waitFor(1)
+		cancel()
+	}()
+	if _, err := coalesceFetch(ctx, ls, key, cached, fetch); !errors.Is(err, context.Canceled) {
+		t.Fatalf("coalesceFetch returned %v, want %v", err, context.Canceled)
+	}
+
+	// A later caller starts its own fetch once the fetch lock of the first one expired, and the cancelled fetch
+	// ending does not unregister it.
+	if err := ls.redisClient.ReleaseFetchLock(context.Background(), key, ls.replicaID); err != nil {
+		t.Fatal(err)
+	}
+	results := make(chan string, 2)
+	call := func() {
+		data, err := coalesceFetch(context.Background(), ls, key, cached, fetch)
+		if err != nil {
+			data = err.Error()
+		}
+		results <- data
+	}
+	go call()
+	waitFor(2)
+	second := registered()
+	close(finish[0])
+	time.Sleep(20 * time.Millisecond)
+	if registered() != second {
+		t.Fatal("the cancelled fetch ending unregistered the fetch of the later caller")
+	}
+
+	// Callers arriving meanwhile share the second fetch.
+	go call()
+	time.Sleep(20 * time.Millisecond)
+	close(finish[1])
+	for i := 0; i < 2; i++ {
+		if data := <-results; data != "fetch 2" {
+			t.Errorf("a later caller got %q, want the result of the second fetch", data)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("got %d fetches, want 2", n)
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+)
+
+// MaxComparedPlayers caps how many players a single comparison can include.
+const MaxComparedPlayers = 10
+
+// lowerIsBetter lists the stats where a smaller value wins a comparison.
+var lowerIsBetter = map[string]bool{"rank": true, "averageRank": true}
+
+// ComparedPlayer is one player's side of a comparison within a game mode.
+type ComparedPlayer struct {
+	Query       string                `json:"query"` // The ID or name the player was requested by
+	Found       bool                  `json:"found"`
+	ID          string                `json:"id,omitempty"`
+	Name        string                `json:"name,omitempty"`
+	Rank        int                   `json:"rank,omitempty"`
+	Tier        string                `json:"tier,omitempty"`
+	SubTier     string                `json:"subTier,omitempty"`
+	Stats       *model.PlayerStats    `json:"stats,omitempty"`
+	Derived     *model.DerivedStats   `json:"derived,omitempty"`
+	Percentiles map[string]float64    `json:"percentiles,omitempty"` // Share of the leaderboard the player matches or beats, per stat
+	attributes  model.PlayerAttribute // Used to compute winners, not serialized
+}
+
+// ModeComparison compares the requested players on the leaderboard of one game mode.
+type ModeComparison struct {
+	GameMode string              `json:"gameMode"`
+	Players  []ComparedPlayer    `json:"players"`
+	Winners  map[string][]string `json:"winners"` // Stat name to the IDs of the players with the best value
+}
+
+// PlayerComparison is the result of comparing players across one or more game modes.
+type PlayerComparison struct {
+	Modes []ModeComparison `json:"modes"`
+}
+
+// ComparePlayers compares players, given by ID or name, on the leaderboards of the given game modes.
+func (ls *LeaderboardService) ComparePlayers(ctx context.Context, queries, gameModes []string) (*PlayerComparison, error) {
+	if len(queries) < 2 || len(queries) > MaxComparedPlayers {
+		return nil, fmt.Errorf("svc: ComparePlayers - between 2 and %d players can be compared, got %d", MaxComparedPlayers, len(queries))
+	}
+
+	comparison := &PlayerComparison{Modes: make([]ModeComparison, 0, len(gameModes))}
+	for _, gameMode := range gameModes {
+		leaderboard, err := ls.GetLeaderboard(ctx, gameMode)
+		if err != nil {
+			return nil, fmt.Errorf("svc: ComparePlayers - failed to get %s leaderboard: %w", gameMode, err)
+		}
+		comparison.Modes = append(comparison.Modes, compareOnLeaderboard(gameMode, leaderboard, queries))
+	}
+
+	return comparison, nil
+}
+
+// compareOnLeaderboard builds the comparison of the queried players on a single leaderboard.
+func compareOnLeaderboard(gameMode string, leaderboard *model.LeaderboardResponse, queries []string) ModeComparison {
+	// Sorted values of every stat across the leaderboard, used to place each player within it.
+	distributions := make(map[string][]float64, len(statValues))
+	for stat, value := range statValues {
+		values := make([]float64, 0, len(leaderboard.Included))
+		for _, player := range leaderboard.Included {
+			values = append(values, value(withDerived(player.Attributes)))
+		}
+		sort.Float64s(values)
+		distributions[stat] = values
+	}
+
+	result := ModeComparison{
+		GameMode: gameMode,
+		Players:  make([]ComparedPlayer, 0, len(queries)),
+		Winners:  make(map[string][]string),
+	}
+
+	for _, query := range queries {
+		compared := ComparedPlayer{Query: query}
+		player, ok := findPlayer(leaderboard, query)
+		if ok {
+			attributes := withDerived(player.Attributes)
+			compared = ComparedPlayer{
+				Query:       query,
+				Found:       true,
+				ID:          player.ID,
+				Name:        attributes.Name,
+				Rank:        attributes.Rank,
+				Tier:        attributes.Stats.Tier,
+				SubTier:     attributes.Stats.SubTier,
+				Stats:       &attributes.Stats,
+				Derived:     attributes.Derived,
+				Percentiles: make(map[string]float64, len(statValues)),
+				attributes:  attributes,
+			}
+			for stat, value := range statValues {
+				compared.Percentiles[stat] = percentileOf(distributions[stat], value(attributes), lowerIsBetter[stat])
+			}
+		}
+		result.Players = append(result.Players, compared)
+	}
+
+	for stat, value := range statValues {
+		var best float64
+		var winners []string
+		for _, compared := range result.Players {
+			if !compared.Found {
+				continue
+			}
+			v := value(compared.attributes)
+			better := v > best
+			if lowerIsBetter[stat] {
+				better = v < best
+			}
+			switch {
+			case winners == nil || better:
+				best, winners = v, []string{compared.ID}
+			case v == best:
+				winners = append(winners, compared.ID)
+			}
+		}
+		if winners != nil {
+			result.Winners[stat] = winners
+		}
+	}
+
+	return result
+}
+
+// findPlayer looks a player up by ID, falling back to a case-insensitive name match.
+func findPlayer(leaderboard *model.LeaderboardResponse, query string) (model.PlayerData, bool) {
+	for _, player := range leaderboard.Included {
+		if player.ID == query {
+			return player, true
+		}
+	}
+	for _, player := range leaderboard.Included {
+		if strings.EqualFold(player.Attributes.Name, query) {
+			return player, true
+		}
+	}
+	return model.PlayerData{}, false
+}
+
+// percentileOf returns the percentage of sorted values that the value matches or beats.
+func percentileOf(sorted []float64, value float64, lowerWins bool) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	var beaten int
+	if lowerWins {
+		// Values greater than or equal to the value.
+		beaten = len(sorted) - sort.SearchFloat64s(sorted, value)
+	} else {
+		// Values less than or equal to the value.
+		beaten = sort.Search(len(sorted), func(i int) bool { return sorted[i] > value })
+	}
+	return 100 * float64(beaten) / float64(len(sorted))
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestPercentileOf(t *testing.T) {
+	sorted := []float64{10, 20, 20, 30, 40}
+
+	tests := []struct {
+		name      string
+		sorted    []float64
+		value     float64
+		lowerWins bool
+		want      float64
+	}{
+		{name: "empty distribution", value: 10, want: 0},
+		{name: "best value", sorted: sorted, value: 40, want: 100},
+		{name: "worst value matches itself", sorted: sorted, value: 10, want: 20},
+		{name: "ties count as matched", sorted: sorted, value: 20, want: 60},
+		{name: "value outside the distribution", sorted: sorted, value: 5, want: 0},
+		{name: "lower wins, best value", sorted: sorted, value: 10, lowerWins: true, want: 100},
+		{name: "lower wins, worst value matches itself", sorted: sorted, value: 40, lowerWins: true, want: 20},
+		{name: "lower wins, ties count as matched", sorted: sorted, value: 20, lowerWins: true, want: 80},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentileOf(tt.sorted, tt.value, tt.lowerWins); !approxEqual(got, tt.want) {
+				t.Errorf("percentileOf(%v, %v, %t) = %v, want %v", tt.sorted, tt.value, tt.lowerWins, got, tt.want)
+			}
+		})
+	}
+}
+
+// compareLeaderboard returns a leaderboard of four players, ranked a to d, with distinct kills and average
+// ranks.
+func compareLeaderboard() *model.LeaderboardResponse {
+	leaderboard := testutil.Leaderboard("squad-fpp", "a", "b", "c", "d")
+	for i, stats := range []struct {
+		kills       int
+		averageRank float64
+	}{{150, 5}, {100, 3}, {150, 3}, {80, 8}} {
+		leaderboard.Included[i].Attributes.Stats.Kills = stats.kills
+		leaderboard.Included[i].Attributes.Stats.AverageRank = stats.averageRank
+	}
+	return leaderboard
+}
+
+func TestCompareOnLeaderboard(t *testing.T) {
+	tests := []struct {
+		name            string
+		queries         []string
+		wantFound       []bool
+		wantWinners     map[string][]string           // Checked for the listed stats only
+		wantPercentiles map[string]map[string]float64 // Player ID to stat to percentile, checked for the listed stats only
+	}{
+		{
+			name:        "higher is better",
+			queries:     []string{"b", "d"},
+			wantFound:   []bool{true, true},
+			wantWinners: map[string][]string{"rankPoints": {"b"}, "kills": {"b"}, "games": {"b", "d"}},
+			wantPercentiles: map[string]map[string]float64{
+				"b": {"rankPoints": 75, "kills": 50},
+				"d": {"rankPoints": 25, "kills": 25},
+			},
+		},
+		{
+			name:        "lower is better",
+			queries:     []string{"a", "b", "d"},
+			wantFound:   []bool{true, true, true},
+			wantWinners: map[string][]string{"rank": {"a"}, "averageRank": {"b"}},
+			wantPercentiles: map[string]map[string]float64{
+				"a": {"rank": 100, "averageRank": 50},
+				"b": {"rank": 75, "averageRank": 100},
+				"d": {"rank": 25, "averageRank": 25},
+			},
+		},
+		{
+			name:        "ties share the win",
+			queries:     []string{"a", "b", "c"},
+			wantFound:   []bool{true, true, true},
+			wantWinners: map[string][]string{"kills": {"a", "c"}, "averageRank": {"b", "c"}},
+			wantPercentiles: map[string]map[string]float64{
+				"a": {"kills": 100},
+				"c": {"kills": 100, "averageRank": 100},
+			},
+		},
+		{
+			name:        "players found by name, case-insensitively",
+			queries:     []string{"PLAYER-C", "player-d"},
+			wantFound:   []bool{true, true},
+			wantWinners: map[string][]string{"rank": {"c"}, "kills": {"c"}},
+		},
+		{
+			name:        "missing players do not win",
+			queries:     []string{"d", "nobody"},
+			wantFound:   []bool{true, false},
+			wantWinners: map[string][]string{"rank": {"d"}, "kills": {"d"}},
+		},
+		{
+			name:        "no players found",
+			queries:     []string{"nobody", "noone"},
+			wantFound:   []bool{false, false},
+			wantWinners: map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison := compareOnLeaderboard("squad-fpp", compareLeaderboard(), tt.queries)
+			if comparison.GameMode != "squad-fpp" || len(comparison.Players) != len(tt.queries) {
+				t.Fatalf("got %d players compared on %s, want %d", len(comparison.Players), comparison.GameMode, len(tt.queries))
+			}
+
+			players := make(map[string]ComparedPlayer)
+			for i, compared := range comparison.Players {
+				if compared.Query != tt.queries[i] || compared.Found != tt.wantFound[i] {
+					t.Errorf("player %d: got query %q found=%t, want %q found=%t", i, compared.Query, compared.Found, tt.queries[i], tt.wantFound[i])
+				}
+				if compared.Found {
+					players[compared.ID] = compared
+				}
+			}
+
+			if len(tt.wantWinners) == 0 && len(comparison.Winners) != 0 {
+				t.Errorf("got winners %v without any player found", comparison.Winners)
+			}
+			for stat, want := range tt.wantWinners {
+				if got := comparison.Winners[stat]; !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: got winners %v, want %v", stat, got, want)
+				}
+			}
+
+			for id, percentiles := range tt.wantPercentiles {
+				for stat, want := range percentiles {
+					if got := players[id].Percentiles[stat]; !approxEqual(got, want) {
+						t.Errorf("%s: got %s percentile %v, want %v", id, stat, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/google/uuid"
+)
+
+// topPlayersThreshold is the rank players must reach to trigger EventPlayerEnteredTop100.
+const topPlayersThreshold = 100
+
+// EventListener is notified of every event the service emits. Listeners are called synchronously from
+// the refresh that detected the event, so they must hand slow work off to their own goroutines.
+type EventListener func(ctx context.Context, event model.Event)
+
+// OnEvent registers a listener for the events emitted by the service.
+func (ls *LeaderboardService) OnEvent(listener EventListener) {
+	ls.listenersMu.Lock()
+	defer ls.listenersMu.Unlock()
+	ls.listeners = append(ls.listeners, listener)
+}
+
+// emit notifies every registered listener of a new event.
+func (ls *LeaderboardService) emit(ctx context.Context, eventType model.EventType, gameMode string, data interface{}) {
+	event := model.Event{
+		ID:       uuid.NewString(),
+		Type:     eventType,
+		Time:     time.Now().UTC(),
+		GameMode: gameMode,
+		Data:     data,
+	}
+
+	ls.listenersMu.RLock()
+	listeners := ls.listeners
+	ls.listenersMu.RUnlock()
+
+	ls.log(ctx).WithField("eventType", eventType).WithField("gameMode", gameMode).Debug("svc: emit - Emitting event")
+	for _, listener := range listeners {
+		listener(ctx, event)
+	}
+}
+
+// emitPlayerEvents emits the events about individual players moving between two versions of a leaderboard.
+func (ls *LeaderboardService) emitPlayerEvents(ctx context.Context, gameMode string, previous, current *model.LeaderboardResponse) {
+	before := make(map[string]model.PlayerAttribute, len(previous.Included))
+	for _, player := range previous.Included {
+		before[player.ID] = player.Attributes
+	}
+
+	for _, player := range current.Included {
+		now := player.Attributes
+		old, existed := before[player.ID]
+
+		if now.Rank <= topPlayersThreshold && (!existed || old.Rank > topPlayersThreshold) {
+			ls.emit(ctx, model.EventPlayerEnteredTop100, gameMode, model.PlayerEventData{
+				ID:      player.ID,
+				Name:    now.Name,
+				Rank:    now.Rank,
+				OldRank: old.Rank,
+			})
+		}
+
+		if existed && (old.Stats.Tier != now.Stats.Tier || old.Stats.SubTier != now.Stats.SubTier) {
+			ls.emit(ctx, model.EventPlayerTierChanged, gameMode, model.PlayerEventData{
+				ID:         player.ID,
+				Name:       now.Name,
+				Rank:       now.Rank,
+				OldRank:    old.Rank,
+				Tier:       now.Stats.Tier,
+				SubTier:    now.Stats.SubTier,
+				OldTier:    old.Stats.Tier,
+				OldSubTier: old.Stats.SubTier,
+			})
+		}
+	}
+}
+
+// recordRefreshResult tracks consecutive refresh failures of a game mode and emits EventRefreshFailed
+// once a streak reaches the configured threshold.
+func (ls *LeaderboardService) recordRefreshResult(ctx context.Context, gameMode string, err error) {
+	// Refreshes cancelled by a shutdown did not fail.
+	if ctx.Err() != nil {
+		return
+	}
+
+	ls.failuresMu.Lock()
+	if err == nil {
+		delete(ls.refreshFailures, gameMode)
+		ls.failuresMu.Unlock()
+		return
+	}
+	ls.refreshFailures[gameMode]++
+	failures := ls.refreshFailures[gameMode]
+	ls.failuresMu.Unlock()
+
+	if failures == ls.failureThreshold {
+		ls.emit(ctx, model.EventRefreshFailed, gameMode, model.RefreshFailedEventData{
+			ConsecutiveFailures: failures,
+			Error:               err.Error(),
+		})
+	}
+}
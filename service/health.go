@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/client"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+)
+
+// healthCheck checks one dependency, returning the details of its status and an error when it is down.
+type healthCheck func(ctx context.Context) (model.HealthCheck, error)
+
+// CheckReadiness runs the readiness checks concurrently within the health check timeout. The replica is ready
+// when every critical check is up, so a replica whose critical dependencies are down receives no traffic.
+func (ls *LeaderboardService) CheckReadiness(ctx context.Context) *model.Readiness {
+	if ls.healthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ls.healthCheckTimeout)
+		defer cancel()
+	}
+
+	// The cache and refresh checks look at the same leaderboards, read once.
+	gameModes := sync.OnceValues(func() (map[string]model.GameModeHealth, error) {
+		return ls.gameModeHealth(ctx)
+	})
+
+	checks := map[string]healthCheck{
+		"redis":   ls.checkRedis,
+		"minio":   ls.checkMinio,
+		"pubg":    ls.checkPUBG,
+		"cache":   func(ctx context.Context) (model.HealthCheck, error) { return checkCache(gameModes()) },
+		"refresh": func(ctx context.Context) (model.HealthCheck, error) { return checkRefresh(gameModes()) },
+	}
+
+	readiness := &model.Readiness{Ready: true, Checks: make(map[string]model.HealthCheck, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		name, check := name, check
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			result, err := check(ctx)
+			result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+			result.Critical = ls.criticalChecks[name]
+			result.Status = model.HealthUp
+			if err != nil {
+				result.Status = model.HealthDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			readiness.Checks[name] = result
+			if err != nil && result.Critical {
+				readiness.Ready = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	return readiness
+}
+
+// checkRedis checks that Redis answers.
+func (ls *LeaderboardService) checkRedis(ctx context.Context) (model.HealthCheck, error) {
+	return model.HealthCheck{}, ls.redisClient.Ping(ctx)
+}
+
+// checkMinio checks that the bucket holding backups and past seasons can be accessed.
+func (ls *LeaderboardService) checkMinio(ctx context.Context) (model.HealthCheck, error) {
+	if ls.minioClient == nil {
+		return model.HealthCheck{}, fmt.Errorf("no MinIO client")
+	}
+
+	exists, err := ls.minioClient.Client.BucketExists(ctx, ls.archiveBucket)
+	if err != nil {
+		return model.HealthCheck{}, err
+	} else if !exists {
+		return model.HealthCheck{}, fmt.Errorf("bucket %q does not exist", ls.archiveBucket)
+	}
+	return model.HealthCheck{}, nil
+}
+
+// checkPUBG checks that the circuit breaker of the PUBG API is not open and that the API is reachable.
+func (ls *LeaderboardService) checkPUBG(ctx context.Context) (model.HealthCheck, error) {
+	state := ls.pubgClient.Breaker().State()
+	result := model.HealthCheck{CircuitBreaker: state.String()}
+	if state == client.BreakerOpen {
+		return result, client.ErrCircuitOpen
+	}
+	return result, ls.pubgClient.Ping(ctx)
+}
+
+// gameModeHealth reads the state of the cached leaderboard of every game mode.
+func (ls *LeaderboardService) gameModeHealth(ctx context.Context) (map[string]model.GameModeHealth, error) {
+	gameModes := make(map[string]model.GameModeHealth, len(ls.gameModes))
+	for _, gameMode := range ls.gameModes {
+		var health model.GameModeHealth
+		if maxAge := ls.maxRefreshAge(gameMode); maxAge > 0 {
+			seconds := maxAge.Seconds()
+			health.MaxAgeSeconds = &seconds
+		}
+
+		leaderboard, err := ls.cachedLeaderboard(ctx, gameMode)
+		if errors.Is(err, store.ErrCacheMiss) {
+			gameModes[gameMode] = health
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read the %s leaderboard: %w", gameMode, err)
+		}
+
+		health.Cached = true
+		if leaderboard.FetchedAt != nil {
+			age := time.Since(*leaderboard.FetchedAt).Seconds()
+			health.LastRefreshAt = leaderboard.FetchedAt
+			health.AgeSeconds = &age
+		}
+		gameModes[gameMode] = health
+	}
+	return gameModes, nil
+}
+
+// maxRefreshAge returns the age of the last refresh of a game mode past which the refresh check is down: the
+// configured one, or else how long its leaderboard is cached. It returns 0 for game modes never refreshed on a
+// schedule, whose age is not checked.
+func (ls *LeaderboardService) maxRefreshAge(gameMode string) time.Duration {
+	if ls.healthMaxRefreshAge > 0 {
+		return ls.healthMaxRefreshAge
+	}
+	return ls.leaderboardTTL(gameMode)
+}
+
+// checkCache checks that the leaderboard of every game mode is cached, so requests are served without waiting on
+// the PUBG API.
+func checkCache(gameModes map[string]model.GameModeHealth, err error) (model.HealthCheck, error) {
+	if err != nil {
+		return model.HealthCheck{}, err
+	}
+
+	result := model.HealthCheck{GameModes: gameModes}
+	var missing []string
+	for gameMode, health := range gameModes {
+		if !health.Cached {
+			missing = append(missing, gameMode)
+		}
+	}
+	if len(missing) > 0 {
+		return result, fmt.Errorf("no cached leaderboard for %s", joinSorted(missing))
+	}
+	return result, nil
+}
+
+// checkRefresh checks that the leaderboard of every game mode was refreshed recently enough.
+func checkRefresh(gameModes map[string]model.GameModeHealth, err error) (model.HealthCheck, error) {
+	if err != nil {
+		return model.HealthCheck{}, err
+	}
+
+	result := model.HealthCheck{GameModes: gameModes}
+	var outdated []string
+	for gameMode, health := range gameModes {
+		switch {
+		case !health.Cached:
+			outdated = append(outdated, gameMode)
+		case health.AgeSeconds != nil && health.MaxAgeSeconds != nil && *health.AgeSeconds > *health.MaxAgeSeconds:
+			outdated = append(outdated, gameMode)
+		}
+	}
+	if len(outdated) > 0 {
+		return result, fmt.Errorf("no recent refresh for %s", joinSorted(outdated))
+	}
+	return result, nil
+}
+
+// joinSorted lists game modes in a stable order for error messages.
+func joinSorted(gameModes []string) string {
+	slices.Sort(gameModes)
+	return strings.Join(gameModes, ", ")
+}
@@ -4,288 +4,718 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gbasileGP/pubg-leaderboard/internal/client"
+	"github.com/gbasileGP/pubg-leaderboard/internal/config"
+	"github.com/gbasileGP/pubg-leaderboard/internal/lifecycle"
+	"github.com/gbasileGP/pubg-leaderboard/internal/logging"
+	"github.com/gbasileGP/pubg-leaderboard/internal/metrics"
 	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/scheduler"
 	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/tracing"
 	"github.com/minio/minio-go/v7"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrUnknownGameMode is returned for game modes the service is not configured to serve.
+var ErrUnknownGameMode = errors.New("game mode not configured")
+
 // LeaderboardService contains methods to interact with leaderboard functionalities.
 type LeaderboardService struct {
 	redisClient *store.RedisClient
 	pubgClient  *client.PUBGClient
 	logger      *logrus.Logger
 	minioClient *store.MinioClient
+	gameModes   []string
+
+	formulasMu sync.RWMutex
+	formulas   map[string]RankingFormula
+
+	live *liveHub
+
+	listenersMu sync.RWMutex
+	listeners   []EventListener
+
+	failuresMu       sync.Mutex
+	refreshFailures  map[string]int // Consecutive refresh failures per game mode
+	failureThreshold int
+
+	archiveBucket string // MinIO bucket holding backups and the final leaderboards of past seasons
+	backupsFile   string // Name backups end with, and the single backup object of earlier versions
+
+	seasonsCacheTTL           time.Duration
+	seasonLeaderboardCacheTTL time.Duration
+
+	jobs            []scheduler.Job          // Scheduled refreshes and backups
+	seasonTTL       time.Duration            // Expiry of the cached current season, derived from its refresh schedule
+	leaderboardTTLs map[string]time.Duration // Expiry of cached leaderboards per game mode, derived from their refresh schedules
+
+	replicaID     string // Identifier this replica holds job leases and fetch locks under
+	leasesEnabled bool   // Whether scheduled jobs only run on the replica holding their lease
+
+	fetchWaitMu  sync.Mutex              // Guards fetchWaiters
+	fetchWaiters map[string]*sharedFetch // Fetches from the PUBG API after cache misses and for stale data in flight, by cache key
+	fetchWait    time.Duration           // How long requests wait for a fetch started by another request
+	fetchLockTTL time.Duration           // Upper bound of a fetch, after which its lock expires
+
+	staleTTL         time.Duration        // How long past their fresh TTL leaderboards are kept to be served stale
+	revalidateMu     sync.Mutex           // Guards lastRevalidation
+	lastRevalidation map[string]time.Time // When a background refresh of a stale leaderboard last started, per game mode
+
+	logSampler *logging.Sampler // Thins out the logs of cache hits and other messages logged on every request
+
+	criticalChecks      map[string]bool // Readiness checks that must be up for the replica to be ready
+	healthCheckTimeout  time.Duration   // Deadline of the readiness checks
+	healthMaxRefreshAge time.Duration   // Configured age of the last refresh past which the refresh check is down
+
+	background *lifecycle.Group // Scheduled jobs and live fan-out, running between Start and Stop
 }
 
 // NewLeaderboardService creates a new service for leaderboard operations.
-func NewLeaderboardService(redisClient *store.RedisClient, pubgClient *client.PUBGClient, minioClient *store.MinioClient, logger *logrus.Logger) *LeaderboardService {
+func NewLeaderboardService(cfg *config.Config, redisClient *store.RedisClient, pubgClient *client.PUBGClient, minioClient *store.MinioClient, logger *logrus.Logger) *LeaderboardService {
 	service := &LeaderboardService{
 		redisClient: redisClient,
 		pubgClient:  pubgClient,
 		minioClient: minioClient,
 		logger:      logger,
+		gameModes:   cfg.GameModes,
+		formulas:    make(map[string]RankingFormula),
+		live:        newLiveHub(),
+
+		refreshFailures:  make(map[string]int),
+		failureThreshold: cfg.RefreshFailureThreshold,
+
+		archiveBucket: cfg.BackupsBucket,
+		backupsFile:   cfg.BackupsFile,
+
+		seasonsCacheTTL:           cfg.SeasonsCacheTTL,
+		seasonLeaderboardCacheTTL: cfg.SeasonLeaderboardCacheTTL,
+
+		leaderboardTTLs: make(map[string]time.Duration, len(cfg.GameModes)),
+
+		replicaID:     cfg.ReplicaID,
+		leasesEnabled: cfg.RefreshLeases,
+
+		fetchWaiters: make(map[string]*sharedFetch),
+		fetchWait:    cfg.FetchWaitTimeout,
+		fetchLockTTL: cfg.FetchLockTTL,
+
+		staleTTL:         cfg.LeaderboardStaleTTL,
+		lastRevalidation: make(map[string]time.Time),
+
+		logSampler: logging.NewSampler(cfg.LogSamplingInitial, cfg.LogSamplingThereafter, time.Second),
+
+		criticalChecks:      make(map[string]bool, len(cfg.HealthCriticalChecks)),
+		healthCheckTimeout:  cfg.HealthCheckTimeout,
+		healthMaxRefreshAge: cfg.HealthMaxRefreshAge,
+
+		background: lifecycle.NewGroup(),
 	}
 
-	// Start the background refreshers within the service initialization
-	go service.startSeasonRefresher()
-	go service.startLeaderboardRefresher()
+	for _, check := range cfg.HealthCriticalChecks {
+		service.criticalChecks[check] = true
+	}
+
+	service.scheduleJobs(cfg.Schedules)
 
 	return service
 }
 
-// startLeaderboardRefresher runs a loop that refreshes the leaderboard every 10 minutes.
-func (ls *LeaderboardService) startLeaderboardRefresher() {
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
+// scheduleJobs builds the scheduled jobs from their configured schedules: the season check, a refresh per game
+// mode and backups. The cache expiry of the data each job refreshes is derived from its schedule. With leases
+// enabled, each job only runs on the replica holding its lease.
+func (ls *LeaderboardService) scheduleJobs(schedules map[string]config.JobSchedule) {
+	addJob := func(name string, run func(ctx context.Context) error) scheduler.Job {
+		schedule := schedules[name]
+		return ls.AddJob(scheduler.Job{
+			Name:       name,
+			Schedule:   schedule.Schedule,
+			Jitter:     schedule.Jitter,
+			RunOnStart: schedule.RunOnStart,
+			Run:        run,
+		})
+	}
 
-	for range ticker.C {
-		err := ls.RefreshLeaderboard(context.Background())
-		if err != nil {
-			ls.logger.WithError(err).Error("svc: startLeaderboardRefresher - Error refreshing leaderboard")
-		} else {
-			ls.logger.Info("svc: startLeaderboardRefresher - Leaderboard refreshed successfully")
+	ls.seasonTTL = addJob("season", ls.RefreshCurrentSeason).TTL()
+
+	for _, gameMode := range ls.gameModes {
+		gameMode := gameMode
+		ls.leaderboardTTLs[gameMode] = addJob("leaderboard:"+gameMode, func(ctx context.Context) error {
+			return ls.RefreshGameMode(ctx, gameMode)
+		}).TTL()
+	}
+
+	addJob("backup", func(ctx context.Context) error {
+		_, err := ls.BackupLeaderboardData(ctx)
+		return err
+	})
+}
+
+// AddJob schedules a job to run between Start and Stop, only on the replica holding its lease when leases are
+// enabled, and returns it. Jobs without a schedule are returned without being added. It must be called before
+// Start.
+func (ls *LeaderboardService) AddJob(job scheduler.Job) scheduler.Job {
+	if job.Schedule == nil {
+		return job
+	}
+	if ls.leasesEnabled {
+		job = ls.leased(job)
+	}
+	ls.jobs = append(ls.jobs, job)
+	return job
+}
+
+// leaderboardTTL returns how long the leaderboard of a game mode and the data derived from it are cached.
+func (ls *LeaderboardService) leaderboardTTL(gameMode string) time.Duration {
+	return ls.leaderboardTTLs[gameMode]
+}
+
+// Start launches the scheduled jobs and the live update fan-out.
+func (ls *LeaderboardService) Start(ctx context.Context) error {
+	for _, job := range ls.jobs {
+		job := job
+		ls.background.Go(func(ctx context.Context) {
+			scheduler.Run(ctx, ls.logger, job)
+		})
+	}
+	ls.background.Go(ls.startLiveFanOut)
+	return nil
+}
+
+// Stop cancels the background work, including refreshes in flight, waits for it to return and releases the
+// leases of this replica.
+func (ls *LeaderboardService) Stop(ctx context.Context) error {
+	if err := ls.background.Stop(ctx); err != nil {
+		return err
+	}
+	if ls.leasesEnabled {
+		return ls.releaseLeases(ctx)
+	}
+	return nil
+}
+
+// log returns the logger of the request being served in ctx, tagged with its request ID, or the service logger
+// outside of requests.
+func (ls *LeaderboardService) log(ctx context.Context) *logrus.Entry {
+	return logging.FromContext(ctx, ls.logger)
+}
+
+// GameModes returns the configured game modes, the first one being the default.
+func (ls *LeaderboardService) GameModes() []string {
+	return ls.gameModes
+}
+
+// DefaultGameMode returns the game mode served by the current-leaderboard endpoints.
+func (ls *LeaderboardService) DefaultGameMode() string {
+	return ls.gameModes[0]
+}
+
+// checkGameMode returns ErrUnknownGameMode unless the game mode is configured.
+func (ls *LeaderboardService) checkGameMode(gameMode string) error {
+	for _, configured := range ls.gameModes {
+		if configured == gameMode {
+			return nil
 		}
 	}
+	return fmt.Errorf("svc: checkGameMode - %q: %w", gameMode, ErrUnknownGameMode)
 }
 
-// RefreshLeaderboard refreshes the leaderboard data and updates the cache.
-func (ls *LeaderboardService) RefreshLeaderboard(ctx context.Context) error {
+// RefreshGameMode refreshes the leaderboard data of a single game mode and updates the cache.
+func (ls *LeaderboardService) RefreshGameMode(ctx context.Context, gameMode string) (err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.RefreshGameMode", trace.WithAttributes(attribute.String("game_mode", gameMode)))
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
 	season, err := ls.GetCurrentSeason(ctx)
 	if err != nil {
-		ls.logger.WithError(err).Error("svc: RefreshLeaderboard - Failed to get current season for leaderboard refresh")
-		return fmt.Errorf("svc: RefreshLeaderboard - failed to get current season for leaderboard refresh: %w", err)
+		ls.log(ctx).WithError(err).WithField("gameMode", gameMode).Error("svc: RefreshGameMode - Failed to get current season for leaderboard refresh")
+		err = fmt.Errorf("svc: RefreshGameMode - failed to get current season for leaderboard refresh: %w", err)
+	} else {
+		err = ls.refreshGameMode(ctx, season.ID, gameMode)
 	}
 
-	leaderboardResp, err := ls.pubgClient.GetSeasonStats(season.ID, "squad-fpp")
-	if err != nil {
-		wrappedErr := fmt.Errorf("svc: RefreshCurrentSeason - failed to refresh leaderboard from PUBG API: %w", err)
-		ls.logger.WithError(wrappedErr).Error("svc: GetSeasonStats - RefreshLeaderboard error")
-		return wrappedErr
+	// Losing the lease hands the refresh over to another replica, it is not a failure.
+	if !errors.Is(err, ErrLeaseLost) {
+		ls.recordRefreshResult(ctx, gameMode, err)
+		ls.observeRefresh(gameMode, time.Since(start), err)
 	}
+	return err
+}
 
-	err = ls.redisClient.UpdateLeaderboard(ctx, leaderboardResp)
+// refreshGameMode fetches the leaderboard of a game mode from the PUBG API and stores it with its summary.
+func (ls *LeaderboardService) refreshGameMode(ctx context.Context, seasonID, gameMode string) error {
+	logger := ls.log(ctx).WithField("gameMode", gameMode)
+
+	leaderboardResp, modified, err := ls.pubgClient.GetSeasonStatsIfModified(ctx, seasonID, gameMode)
 	if err != nil {
-		wrappedErr := fmt.Errorf("svc: UpdateLeaderboard - failed to update leaderboard in Redis: %w", err)
-		ls.logger.WithError(wrappedErr).Error("svc: UpdateLeaderboard - RefreshLeaderboard error")
+		wrappedErr := fmt.Errorf("svc: refreshGameMode - failed to fetch leaderboard from PUBG API: %w", err)
+		logger.WithError(wrappedErr).Error("svc: refreshGameMode - Failed to fetch leaderboard from PUBG API")
 		return wrappedErr
 	}
 
-	ls.logger.Info("svc: UpdateLeaderboard - Refreshed and updated leaderboard in Redis")
-	return nil
-}
+	fetchedAt := time.Now().UTC()
 
-// startSeasonRefresher runs a loop that refreshes the current season daily.
-func (ls *LeaderboardService) startSeasonRefresher() {
-	// Refresh immediately on startup, then use the ticker for subsequent refreshes
-	err := ls.RefreshCurrentSeason(context.Background())
-	if err != nil {
-		ls.logger.WithError(err).Error("svc: startSeasonRefresher - Routine Refresher Error")
-	} else {
-		ls.logger.Info("svc: startSeasonRefresher - Current season refreshed successfully")
+	// An unchanged leaderboard only has its cache extended: nothing is rewritten and no change is published, but
+	// the ranks of watched players are still recorded.
+	if !modified {
+		if err := ls.checkFence(ctx); err != nil {
+			logger.WithError(err).Warn("svc: refreshGameMode - Not extending leaderboard")
+			return err
+		}
+
+		err := ls.redisClient.TouchLeaderboard(ctx, gameMode, leaderboardResp, fetchedAt, fenceToken(ctx), ls.leaderboardHardTTL(gameMode))
+		if errors.Is(err, store.ErrStaleFence) {
+			err = fencedWriteError(ctx, err)
+			logger.WithError(err).Warn("svc: refreshGameMode - Not extending leaderboard")
+			return err
+		} else if err == nil {
+			if err := ls.redisClient.ExtendLeaderboardSummary(ctx, gameMode, ls.leaderboardTTL(gameMode)); err != nil {
+				logger.WithError(err).Warn("svc: refreshGameMode - Failed to extend leaderboard summary in Redis")
+			}
+			ls.observePlayers(gameMode, leaderboardResp)
+			ls.trackWatchlists(ctx, gameMode, seasonID, nil, leaderboardResp)
+			logger.Info("svc: refreshGameMode - Leaderboard unchanged, extended it in Redis")
+			return nil
+		} else if err != store.ErrCacheMiss {
+			wrappedErr := fmt.Errorf("svc: refreshGameMode - failed to extend leaderboard in Redis: %w", err)
+			logger.WithError(wrappedErr).Error("svc: refreshGameMode - Failed to extend leaderboard in Redis")
+			return wrappedErr
+		}
+		logger.Info("svc: refreshGameMode - Leaderboard unchanged but missing from Redis, storing it")
 	}
 
-	ticker := time.NewTicker(24 * time.Hour)
-	defer ticker.Stop()
+	enrichLeaderboard(leaderboardResp)
+	leaderboardResp.FetchedAt = &fetchedAt
 
-	for range ticker.C {
-		err := ls.RefreshCurrentSeason(context.Background())
-		if err != nil {
-			ls.logger.WithError(err).Error("svc: startSeasonRefresher - Routine Refresher Error")
-		} else {
-			ls.logger.Info("svc: startSeasonRefresher - Current season refreshed successfully")
-		}
+	// Keep the board being replaced so live subscribers can be told what changed.
+	previous, err := ls.redisClient.GetLeaderboard(ctx, gameMode)
+	if err != nil && err != store.ErrCacheMiss {
+		logger.WithError(err).Warn("svc: refreshGameMode - Failed to read previous leaderboard from Redis, no diff will be published")
+	}
+
+	if err := ls.checkFence(ctx); err != nil {
+		logger.WithError(err).Warn("svc: refreshGameMode - Not storing leaderboard")
+		return err
 	}
+
+	err = ls.redisClient.UpdateLeaderboard(ctx, gameMode, leaderboardResp, fenceToken(ctx), ls.leaderboardHardTTL(gameMode))
+	if errors.Is(err, store.ErrStaleFence) {
+		err = fencedWriteError(ctx, err)
+		logger.WithError(err).Warn("svc: refreshGameMode - Not storing leaderboard")
+		return err
+	} else if err != nil {
+		wrappedErr := fmt.Errorf("svc: refreshGameMode - failed to update leaderboard in Redis: %w", err)
+		logger.WithError(wrappedErr).Error("svc: refreshGameMode - Failed to update leaderboard in Redis")
+		return wrappedErr
+	}
+	ls.observePlayers(gameMode, leaderboardResp)
+
+	if previous != nil {
+		ls.publishDiff(ctx, gameMode, seasonID, previous, leaderboardResp)
+		ls.emitPlayerEvents(ctx, gameMode, previous, leaderboardResp)
+	}
+	ls.trackWatchlists(ctx, gameMode, seasonID, previous, leaderboardResp)
+	ls.publishReport(ctx, gameMode, seasonID, previous, leaderboardResp)
+
+	// The summary is secondary data, a failure to store it does not fail the refresh.
+	summary := SummarizeLeaderboard(gameMode, seasonID, leaderboardResp)
+	if err := ls.redisClient.UpdateLeaderboardSummary(ctx, summary, ls.leaderboardTTL(gameMode)); err != nil {
+		logger.WithError(err).Warn("svc: refreshGameMode - Failed to update leaderboard summary in Redis")
+	}
+
+	logger.Info("svc: refreshGameMode - Refreshed and updated leaderboard in Redis")
+	return nil
 }
 
 // RefreshCurrentSeason refreshes the current season data and updates the cache.
-func (ls *LeaderboardService) RefreshCurrentSeason(ctx context.Context) error {
-	currentSeason, err := ls.pubgClient.GetCurrentSeason()
+func (ls *LeaderboardService) RefreshCurrentSeason(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.RefreshCurrentSeason")
+	defer func() { tracing.End(span, err) }()
+
+	seasons, err := ls.pubgClient.GetSeasons(ctx)
+	var currentSeason *model.SeasonData
+	if err == nil {
+		currentSeason, err = client.CurrentSeason(seasons)
+	}
 	if err != nil {
 		wrappedErr := fmt.Errorf("svc: RefreshCurrentSeason - failed to refresh current season from PUBG API: %w", err)
-		ls.logger.WithError(wrappedErr).Error("svc: RefreshCurrentSeason - Failed to refresh current season from PUBG API")
+		ls.log(ctx).WithError(wrappedErr).Error("svc: RefreshCurrentSeason - Failed to refresh current season from PUBG API")
+		return wrappedErr
+	}
+
+	if err := ls.checkFence(ctx); err != nil {
+		ls.log(ctx).WithError(err).Warn("svc: RefreshCurrentSeason - Not storing current season")
+		return err
+	}
+
+	// The list of seasons only changes at rollovers, which this refresh is responsible for noticing.
+	if err := ls.redisClient.UpdateSeasons(ctx, seasons, ls.seasonsCacheTTL); err != nil {
+		ls.log(ctx).WithError(err).Warn("svc: RefreshCurrentSeason - Failed to update seasons in Redis")
+	}
+
+	// A season other than the one this job last saw means the season rolled over since its last run. The cached
+	// current season is not compared against, as it expires and requests refill it with the new season. The
+	// outgoing season is archived before the new one is recorded.
+	previousSeasonID, err := ls.redisClient.GetLastSeenSeason(ctx)
+	if errors.Is(err, store.ErrCacheMiss) {
+		// Deployments upgraded from versions that did not record the season start from the cached one.
+		if cached, cacheErr := ls.redisClient.GetSeason(ctx); cacheErr == nil {
+			previousSeasonID = cached.ID
+		}
+	} else if err != nil {
+		wrappedErr := fmt.Errorf("svc: RefreshCurrentSeason - failed to get last seen season from Redis: %w", err)
+		ls.log(ctx).WithError(wrappedErr).Error("svc: RefreshCurrentSeason - Failed to get last seen season from Redis")
 		return wrappedErr
 	}
+	if previousSeasonID != "" && previousSeasonID != currentSeason.ID {
+		ls.log(ctx).WithField("seasonID", currentSeason.ID).WithField("previousSeasonID", previousSeasonID).Info("svc: RefreshCurrentSeason - Season rolled over")
+		ls.emit(ctx, model.EventNewSeason, "", model.SeasonEventData{
+			SeasonID:         currentSeason.ID,
+			PreviousSeasonID: previousSeasonID,
+		})
+		ls.archiveSeason(ctx, previousSeasonID)
+	}
+	if previousSeasonID != currentSeason.ID {
+		if err := ls.redisClient.UpdateLastSeenSeason(ctx, currentSeason.ID); err != nil {
+			wrappedErr := fmt.Errorf("svc: RefreshCurrentSeason - failed to record current season in Redis: %w", err)
+			ls.log(ctx).WithError(wrappedErr).Error("svc: RefreshCurrentSeason - Failed to record current season in Redis")
+			return wrappedErr
+		}
+	}
 
-	err = ls.redisClient.UpdateSeason(ctx, currentSeason)
+	err = ls.redisClient.UpdateSeason(ctx, currentSeason, ls.seasonTTL)
 	if err != nil {
 		wrappedErr := fmt.Errorf("svc: UpdateSeason - failed to update current season in Redis: %w", err)
-		ls.logger.WithError(wrappedErr).Error("svc: RefreshCurrentSeason - Failed to update current season in Redis")
+		ls.log(ctx).WithError(wrappedErr).Error("svc: RefreshCurrentSeason - Failed to update current season in Redis")
 		return wrappedErr
 	}
 
-	ls.logger.Info("svc: UpdateSeason - Successfully refreshed and updated current season in Redis")
+	ls.log(ctx).Info("svc: UpdateSeason - Successfully refreshed and updated current season in Redis")
 	return nil
 }
 
 // GetCurrentSeason retrieves the current season from Redis or the external API.
-func (ls *LeaderboardService) GetCurrentSeason(ctx context.Context) (*model.SeasonData, error) {
-	seasonData, err := ls.redisClient.GetSeason(ctx)
+func (ls *LeaderboardService) GetCurrentSeason(ctx context.Context) (seasonData *model.SeasonData, err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.GetCurrentSeason")
+	defer func() { tracing.End(span, err) }()
+
+	seasonData, err = ls.redisClient.GetSeason(ctx)
 	if err != nil {
 		if err == store.ErrCacheMiss {
 			// If data is not found in Redis, log the cache miss and continue to fetch from the PUBG API.
-			ls.logger.Info("svc: GetCurrentSeason - Cache miss in Redis, fetching from PUBG API")
+			metrics.CacheRequests.WithLabelValues("season", "miss").Inc()
+			ls.log(ctx).Info("svc: GetCurrentSeason - Cache miss in Redis, fetching from PUBG API")
 		} else {
 			// For actual errors, log and return the error.
-			ls.logger.WithError(err).Error("Service: GetCurrentSeason - Failed to retrieve current season from Redis")
+			ls.log(ctx).WithError(err).Error("Service: GetCurrentSeason - Failed to retrieve current season from Redis")
 			return nil, err
 		}
 	} else if seasonData != nil {
 		// If data is successfully retrieved from Redis, return it.
-		ls.logger.Info("svc: GetCurrentSeason - Retrieved current season from Redis")
+		metrics.CacheRequests.WithLabelValues("season", "hit").Inc()
+		ls.logSampler.Info(ls.log(ctx), "svc: GetCurrentSeason - Retrieved current season from Redis")
 		return seasonData, nil
 	}
 
-	// Fetch the current season from the PUBG API if it's not in Redis or Redis had an actual error
-	currentSeason, err := ls.pubgClient.GetCurrentSeason()
+	// Concurrent misses share a single fetch from the PUBG API, across replicas too.
+	return coalesceFetch(ctx, ls, "current_season", ls.redisClient.GetSeason, ls.fetchCurrentSeason)
+}
+
+// fetchCurrentSeason fetches the current season from the PUBG API and caches it.
+func (ls *LeaderboardService) fetchCurrentSeason(ctx context.Context) (*model.SeasonData, error) {
+	currentSeason, err := ls.pubgClient.GetCurrentSeason(ctx)
 	if err != nil {
 		wrappedErr := fmt.Errorf("svc: GetCurrentSeason - failed to fetch current season from PUBG API: %w", err)
-		ls.logger.WithError(wrappedErr).Error("svc: GetCurrentSeason - fFailed to fetch current season from PUBG API")
+		ls.log(ctx).WithError(wrappedErr).Error("svc: GetCurrentSeason - fFailed to fetch current season from PUBG API")
 		return nil, wrappedErr
 	}
 
 	// Attempt to update the season in Redis after fetching from the PUBG API
-	err = ls.redisClient.UpdateSeason(ctx, currentSeason)
+	err = ls.redisClient.UpdateSeason(ctx, currentSeason, ls.seasonTTL)
 	if err != nil {
 		wrappedErr := fmt.Errorf("svc: GetCurrentSeason - Failed to update current season in Redis: %w", err)
-		ls.logger.WithError(wrappedErr).Warn("svc: GetCurrentSeason - Failed to update current season in Redis, but season was fetched from PUBG API")
+		ls.log(ctx).WithError(wrappedErr).Warn("svc: GetCurrentSeason - Failed to update current season in Redis, but season was fetched from PUBG API")
 	} else {
-		ls.logger.Info("svc: GetCurrentSeason - Updated current season in Redis")
+		ls.log(ctx).Info("svc: GetCurrentSeason - Updated current season in Redis")
 	}
 
 	return currentSeason, nil
 }
 
+// GetCurrentLeaderboard retrieves the leaderboard of the default game mode.
 func (ls *LeaderboardService) GetCurrentLeaderboard(ctx context.Context) (*model.LeaderboardResponse, error) {
-	season, err := ls.GetCurrentSeason(ctx)
-	if err != nil {
-		ls.logger.WithError(err).Error("svc: GetCurrentLeaderboard - Failed to get current season for leaderboard retrieval")
-		return nil, fmt.Errorf("svc: GetCurrentLeaderboard - failed to get current season for leaderboard retrieval: %w", err)
+	return ls.GetLeaderboard(ctx, ls.DefaultGameMode())
+}
+
+// GetLeaderboard retrieves the leaderboard of a game mode from Redis or the external API. Leaderboards past their
+// fresh TTL are served marked stale while a background refresh replaces them, and the latest backup is served
+// when nothing is cached and the PUBG API fails or its circuit breaker is open.
+func (ls *LeaderboardService) GetLeaderboard(ctx context.Context, gameMode string) (leaderboard *model.LeaderboardResponse, err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.GetLeaderboard", trace.WithAttributes(attribute.String("game_mode", gameMode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := ls.checkGameMode(gameMode); err != nil {
+		return nil, err
 	}
 
+	logger := ls.log(ctx).WithField("gameMode", gameMode)
+
 	// Attempt to retrieve the leaderboard from Redis
-	leaderboard, err := ls.redisClient.GetLeaderboard(ctx)
-	if err != nil {
-		if err == store.ErrCacheMiss {
-			ls.logger.Info("svc: GetCurrentLeaderboard - Leaderboard cache miss in Redis, fetching from PUBG API")
-		} else {
-			// Log and return other Redis errors
-			wrappedErr := fmt.Errorf("svc: GetCurrentLeaderboard - Failed to retrieve leaderboard from Redis: %w", err)
-			ls.logger.WithError(wrappedErr).Error("svc: GetCurrentLeaderboard - Failed to retrieve leaderboard from Redis")
-			return nil, wrappedErr
-		}
-	} else {
-		// Successfully retrieved leaderboard from Redis
-		ls.logger.Info("svc: GetCurrentLeaderboard - Retrieved leaderboard from Redis")
+	leaderboard, err = ls.cachedLeaderboard(ctx, gameMode)
+	switch {
+	case err == nil && ls.isFresh(gameMode, leaderboard):
+		metrics.CacheRequests.WithLabelValues("leaderboard", "hit").Inc()
+		ls.logSampler.Info(logger, "svc: GetLeaderboard - Retrieved leaderboard from Redis")
+		return leaderboard, nil
+	case err == nil:
+		metrics.CacheRequests.WithLabelValues("leaderboard", "stale").Inc()
+		ls.logSampler.Info(logger, "svc: GetLeaderboard - Serving stale leaderboard from Redis while refreshing it")
+		ls.revalidate(gameMode)
+		leaderboard.Stale = true
 		return leaderboard, nil
+	case err == store.ErrCacheMiss:
+		metrics.CacheRequests.WithLabelValues("leaderboard", "miss").Inc()
+		logger.Info("svc: GetLeaderboard - Leaderboard cache miss in Redis, fetching from PUBG API")
+	default:
+		// Log and return other Redis errors
+		wrappedErr := fmt.Errorf("svc: GetLeaderboard - Failed to retrieve leaderboard from Redis: %w", err)
+		logger.WithError(wrappedErr).Error("svc: GetLeaderboard - Failed to retrieve leaderboard from Redis")
+		return nil, wrappedErr
+	}
+
+	// While the circuit breaker is open the PUBG API is not called, misses go straight to the latest backup.
+	// Otherwise concurrent misses share a single fetch from the PUBG API, across replicas too.
+	if ls.pubgUnavailable() {
+		err = fmt.Errorf("svc: GetLeaderboard - PUBG API unavailable: %w", client.ErrCircuitOpen)
+	} else {
+		leaderboard, err = coalesceFetch(ctx, ls, leaderboardCacheKey(gameMode), ls.freshLeaderboardFunc(gameMode), ls.fetchLeaderboardFunc(gameMode))
+		if err == nil {
+			return leaderboard, nil
+		}
 	}
 
-	// Fetch from the PUBG API as either there was a cache miss or another Redis error
-	leaderboardResp, err := ls.pubgClient.GetSeasonStats(season.ID, "squad-fpp")
+	backup, backupErr := ls.readLatestBackup(ctx, gameMode)
+	if backupErr != nil {
+		logger.WithError(backupErr).Warn("svc: GetLeaderboard - No backup to fall back to")
+		return nil, err
+	}
+	logger.WithError(err).Warn("svc: GetLeaderboard - Serving leaderboard from the latest backup")
+	ls.revalidate(gameMode)
+	return backup, nil
+}
+
+// leaderboardCacheKey names the cached leaderboard of a game mode for request coalescing.
+func leaderboardCacheKey(gameMode string) string {
+	return "leaderboard:" + gameMode
+}
+
+// fetchLeaderboard fetches the leaderboard of a game mode from the PUBG API and caches it.
+func (ls *LeaderboardService) fetchLeaderboard(ctx context.Context, seasonID, gameMode string) (*model.LeaderboardResponse, error) {
+	logger := ls.log(ctx).WithField("gameMode", gameMode)
+
+	leaderboardResp, err := ls.pubgClient.GetSeasonStats(ctx, seasonID, gameMode)
 	if err != nil {
 		wrappedErr := fmt.Errorf("svc: GetSeasonStats - failed to fetch leaderboard from PUBG API: %w", err)
-		ls.logger.WithError(wrappedErr).Error("svc: GetSeasonStats - Failed to fetch leaderboard from PUBG API")
+		logger.WithError(wrappedErr).Error("svc: GetSeasonStats - Failed to fetch leaderboard from PUBG API")
 		return nil, wrappedErr
 	}
 
+	enrichLeaderboard(leaderboardResp)
+	fetchedAt := time.Now().UTC()
+	leaderboardResp.FetchedAt = &fetchedAt
+
+	// A stale leaderboard may still be cached, keep it so live subscribers can be told what changed.
+	previous, err := ls.redisClient.GetLeaderboard(ctx, gameMode)
+	if err != nil && err != store.ErrCacheMiss {
+		logger.WithError(err).Warn("svc: fetchLeaderboard - Failed to read previous leaderboard from Redis, no diff will be published")
+	}
+
 	// Update the cache with the new leaderboard data after successful fetch from PUBG API
-	err = ls.redisClient.UpdateLeaderboard(ctx, leaderboardResp)
+	err = ls.redisClient.UpdateLeaderboard(ctx, gameMode, leaderboardResp, 0, ls.leaderboardHardTTL(gameMode))
 	if err != nil {
 		wrappedErr := fmt.Errorf("svc: UpdateLeaderboard - failed to update leaderboard in Redis: %w", err)
-		ls.logger.WithError(wrappedErr).Warn("svc: UpdateLeaderboard - Failed to update leaderboard in Redis, but returning latest data from PUBG API")
+		logger.WithError(wrappedErr).Warn("svc: UpdateLeaderboard - Failed to update leaderboard in Redis, but returning latest data from PUBG API")
 	} else {
-		ls.logger.Info("svc: UpdateLeaderboard - Updated leaderboard in Redis with the latest data from PUBG API")
+		ls.observePlayers(gameMode, leaderboardResp)
+		if previous != nil {
+			ls.publishDiff(ctx, gameMode, seasonID, previous, leaderboardResp)
+		}
+		logger.Info("svc: UpdateLeaderboard - Updated leaderboard in Redis with the latest data from PUBG API")
 	}
 
 	return leaderboardResp, nil
 }
 
-// GetPlayerStats retrieves specific stats for a single player.
-func (ls *LeaderboardService) GetPlayerStats(ctx context.Context, playerID string) (int, int, int, error) {
-	playerStats, err := ls.redisClient.GetPlayerStats(ctx, playerID)
+// GetPlayerStats retrieves specific stats for a single player in a game mode.
+func (ls *LeaderboardService) GetPlayerStats(ctx context.Context, gameMode, playerID string) (rank, gamesPlayed, wins int, err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.GetPlayerStats", trace.WithAttributes(attribute.String("game_mode", gameMode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := ls.checkGameMode(gameMode); err != nil {
+		return 0, 0, 0, err
+	}
+
+	playerStats, err := ls.redisClient.GetPlayerStats(ctx, gameMode, playerID)
+	if err == store.ErrCacheMiss {
+		metrics.CacheRequests.WithLabelValues("player_stats", "miss").Inc()
+	} else if err == nil {
+		metrics.CacheRequests.WithLabelValues("player_stats", "hit").Inc()
+	}
 	if err != nil {
-		ls.logger.WithError(err).WithField("playerID", playerID).Error("svc: GetPlayerStats - Failed to retrieve player stats from Redis")
+		ls.log(ctx).WithError(err).WithField("playerID", playerID).Error("svc: GetPlayerStats - Failed to retrieve player stats from Redis")
 		return 0, 0, 0, err
 	}
 
 	if playerStats == nil {
-		ls.logger.WithField("playerID", playerID).Info("svc: GetPlayerStats - Player stats not found in Redis")
+		ls.logSampler.Info(ls.log(ctx).WithField("playerID", playerID), "svc: GetPlayerStats - Player stats not found in Redis")
 		return 0, 0, 0, store.ErrCacheMiss
 	}
 
 	// Extracting the required information from playerStats.
-	currentRank := playerStats.Rank
-	gamesPlayed := playerStats.Stats.Games
-	wins := playerStats.Stats.Wins
+	rank = playerStats.Rank
+	gamesPlayed = playerStats.Stats.Games
+	wins = playerStats.Stats.Wins
 
-	ls.logger.WithFields(logrus.Fields{
+	ls.logSampler.Info(ls.log(ctx).WithFields(logrus.Fields{
 		"playerID":    playerID,
-		"currentRank": currentRank,
+		"currentRank": rank,
 		"gamesPlayed": gamesPlayed,
 		"wins":        wins,
-	}).Info("svc: GetPlayerStats - Successfully retrieved player stats")
+	}), "svc: GetPlayerStats - Successfully retrieved player stats")
+
+	return rank, gamesPlayed, wins, nil
+}
+
+// backupPrefix is the prefix of the backups of a game mode in the backups bucket.
+func backupPrefix(gameMode string) string {
+	return "backups/" + gameMode + "/"
+}
+
+// backupObject names the backup of a game mode taken at a given time. Names sort in the order backups were taken.
+func (ls *LeaderboardService) backupObject(gameMode string, at time.Time) string {
+	return backupPrefix(gameMode) + at.UTC().Format("20060102T150405.000Z") + "-" + ls.backupsFile
+}
+
+// BackupLeaderboardData backs up the leaderboard of every configured game mode to the backups bucket in MinIO,
+// returning the objects written. A game mode that cannot be backed up does not prevent the others.
+func (ls *LeaderboardService) BackupLeaderboardData(ctx context.Context) (objects []string, err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.BackupLeaderboardData")
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
+	defer func() {
+		metrics.BackupDuration.WithLabelValues("backup", metrics.Result(err)).Observe(time.Since(start).Seconds())
+	}()
+
+	var errs []error
+	for _, gameMode := range ls.gameModes {
+		object := ls.backupObject(gameMode, start)
+		if err := ls.backupGameMode(ctx, gameMode, object); err != nil {
+			errs = append(errs, fmt.Errorf("svc: BackupLeaderboardData - %s: %w", gameMode, err))
+			continue
+		}
+		objects = append(objects, object)
+	}
 
-	return currentRank, gamesPlayed, wins, nil
+	return objects, errors.Join(errs...)
 }
 
-// BackupLeaderboardData creates a backup of the leaderboard data to MinIO.
-func (ls *LeaderboardService) BackupLeaderboardData(ctx context.Context, bucketName, backupFileName string) error {
+// backupGameMode writes the leaderboard of a game mode to an object of the backups bucket.
+func (ls *LeaderboardService) backupGameMode(ctx context.Context, gameMode, object string) error {
+	logger := ls.log(ctx).WithField("gameMode", gameMode)
+
 	// Retrieve the current leaderboard data that needs to be backed up.
-	leaderboardData, err := ls.GetCurrentLeaderboard(ctx)
+	leaderboardData, err := ls.GetLeaderboard(ctx, gameMode)
 	if err != nil {
-		ls.logger.WithError(err).Error("Failed to get current leaderboard for backup")
+		logger.WithError(err).Error("Failed to get current leaderboard for backup")
 		return err
 	}
 
-	// Serialize the leaderboard data to JSON or another preferred format.
-	data, err := json.Marshal(leaderboardData)
+	// Serialize the leaderboard data to JSON or another preferred format. The fetch time is kept so data served
+	// from the backup reports its real age.
+	backup := *leaderboardData
+	backup.Stale = false
+	data, err := json.Marshal(backup)
 	if err != nil {
-		ls.logger.WithError(err).Error("Failed to serialize leaderboard data for backup")
+		logger.WithError(err).Error("Failed to serialize leaderboard data for backup")
 		return err
 	}
 
 	// Upload the serialized data to MinIO.
-	_, err = ls.minioClient.Client.PutObject(ctx, bucketName, backupFileName, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+	_, err = ls.minioClient.Client.PutObject(ctx, ls.archiveBucket, object, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
 		ContentType: "application/json",
 	})
 	if err != nil {
-		ls.logger.WithError(err).Error("Failed to backup leaderboard data to MinIO")
+		logger.WithError(err).Error("Failed to backup leaderboard data to MinIO")
 		return err
 	}
+	metrics.BackupSize.WithLabelValues("backup").Observe(float64(len(data)))
 
-	ls.logger.Info("Leaderboard data backed up successfully")
+	logger.WithField("object", object).Info("Leaderboard data backed up successfully")
 	return nil
 }
 
-// RestoreLeaderboardData restores the leaderboard data from a backup in MinIO.
-func (ls *LeaderboardService) RestoreLeaderboardData(ctx context.Context, bucketName, backupFileName string) error {
+// RestoreLeaderboardData restores the leaderboard data from a backup object of the backups bucket in MinIO.
+func (ls *LeaderboardService) RestoreLeaderboardData(ctx context.Context, backupFileName string) (err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.RestoreLeaderboardData")
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
+	defer func() {
+		metrics.BackupDuration.WithLabelValues("restore", metrics.Result(err)).Observe(time.Since(start).Seconds())
+	}()
+
 	// Download the backup file from MinIO.
-	object, err := ls.minioClient.Client.GetObject(ctx, bucketName, backupFileName, minio.GetObjectOptions{})
+	object, err := ls.minioClient.Client.GetObject(ctx, ls.archiveBucket, backupFileName, minio.GetObjectOptions{})
 	if err != nil {
-		ls.logger.WithError(err).Error("Failed to retrieve leaderboard backup from MinIO")
+		ls.log(ctx).WithError(err).Error("Failed to retrieve leaderboard backup from MinIO")
 		return err
 	}
 	defer object.Close()
 
-	// Deserialize the downloaded data into the leaderboard structure.
+	// Deserialize the downloaded data into the leaderboard structure, counting its size.
 	var leaderboardData model.LeaderboardResponse
-	err = json.NewDecoder(object).Decode(&leaderboardData)
+	body := &countingReader{r: object}
+	err = json.NewDecoder(body).Decode(&leaderboardData)
 	if err != nil {
-		ls.logger.WithError(err).Error("Failed to deserialize leaderboard data from backup")
+		ls.log(ctx).WithError(err).Error("Failed to deserialize leaderboard data from backup")
 		return err
 	}
 
+	// Backups taken before derived stats existed lack them, so compute them again.
+	enrichLeaderboard(&leaderboardData)
+
+	// Backups record their game mode; older ones without it belong to the default game mode.
+	gameMode := leaderboardData.Data.Attributes.GameMode
+	if gameMode == "" {
+		gameMode = ls.DefaultGameMode()
+	}
+
+	// Keep the leaderboard being replaced so live subscribers can be told what the restore changed.
+	previous, err := ls.redisClient.GetLeaderboard(ctx, gameMode)
+	if err != nil && err != store.ErrCacheMiss {
+		ls.log(ctx).WithError(err).Warn("Failed to read the leaderboard being restored over from Redis, no diff will be published")
+	}
+
 	// Update the Redis store with the restored leaderboard data.
-	err = ls.redisClient.UpdateLeaderboard(ctx, &leaderboardData)
+	err = ls.redisClient.UpdateLeaderboard(ctx, gameMode, &leaderboardData, 0, ls.leaderboardHardTTL(gameMode))
 	if err != nil {
-		ls.logger.WithError(err).Error("Failed to update Redis with the restored leaderboard data")
+		ls.log(ctx).WithError(err).Error("Failed to update Redis with the restored leaderboard data")
 		return err
 	}
+	metrics.BackupSize.WithLabelValues("restore").Observe(float64(body.n))
+	ls.observePlayers(gameMode, &leaderboardData)
+	if previous != nil {
+		ls.publishDiff(ctx, gameMode, leaderboardData.Data.Attributes.SeasonId, previous, &leaderboardData)
+	}
 
-	ls.logger.Info("Leaderboard data restored successfully")
+	ls.log(ctx).Info("Leaderboard data restored successfully")
 	return nil
 }
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/scheduler"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+)
+
+// ErrLeaseLost is returned by leased runs whose replica lost the lease of the job before writing its results.
+var ErrLeaseLost = errors.New("lease lost to another replica")
+
+// leaseGrace is added to the longest gap between runs of a job to get the duration of its lease, leaving the
+// holder time to renew it at every run.
+const leaseGrace = 30 * time.Second
+
+// fenceKey is the context key of the fence of a leased run.
+type fenceKey struct{}
+
+// fence identifies the lease a run was started under.
+type fence struct {
+	job   string
+	token int64
+}
+
+// leased wraps a job so that only the replica holding its lease runs it. A replica keeps the lease by renewing
+// it on every run and while the run is in progress, making it the leader of the job. Other replicas skip their
+// runs until the lease expires, so a replica that dies is taken over at most one lease duration later.
+func (ls *LeaderboardService) leased(job scheduler.Job) scheduler.Job {
+	ttl := scheduler.MaxInterval(job.Schedule) + job.Jitter + leaseGrace
+	run := job.Run
+	logger := ls.logger.WithField("job", job.Name).WithField("replica", ls.replicaID)
+
+	job.Run = func(ctx context.Context) error {
+		token, acquired, err := ls.redisClient.AcquireLease(ctx, job.Name, ls.replicaID, ttl)
+		if err != nil {
+			return fmt.Errorf("svc: leased - failed to acquire lease: %w", err)
+		}
+		if !acquired {
+			logger.Debug("svc: leased - Lease held by another replica, skipping run")
+			return nil
+		}
+
+		// The run is cancelled as soon as the lease cannot be renewed, so a replica that lost it stops writing.
+		runCtx, cancelRun := context.WithCancelCause(ctx)
+		defer cancelRun(nil)
+		renewCtx, stopRenewing := context.WithCancel(runCtx)
+		renewed := make(chan struct{})
+		go func() {
+			defer close(renewed)
+			ls.renewLease(renewCtx, job.Name, ttl, func() { cancelRun(ErrLeaseLost) })
+		}()
+
+		err = run(context.WithValue(runCtx, fenceKey{}, fence{job: job.Name, token: token}))
+		stopRenewing()
+		<-renewed
+
+		if errors.Is(context.Cause(runCtx), ErrLeaseLost) {
+			logger.Warn("svc: leased - Lease lost during run")
+			return fmt.Errorf("svc: leased - %s: %w", job.Name, ErrLeaseLost)
+		}
+
+		// Renew from the end of the run so the lease lasts until the next one.
+		if _, _, renewErr := ls.redisClient.AcquireLease(ctx, job.Name, ls.replicaID, ttl); renewErr != nil && ctx.Err() == nil {
+			logger.WithError(renewErr).Warn("svc: leased - Failed to renew lease after run")
+		}
+		return err
+	}
+	return job
+}
+
+// renewLease renews the lease of a job until ctx is done, calling lost if another replica took it over or it
+// could not be renewed before expiring.
+func (ls *LeaderboardService) renewLease(ctx context.Context, jobName string, ttl time.Duration, lost func()) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	expiresAt := time.Now().Add(ttl)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, held, err := ls.redisClient.AcquireLease(ctx, jobName, ls.replicaID, ttl)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil && time.Now().Before(expiresAt):
+			ls.log(ctx).WithError(err).WithField("job", jobName).Warn("svc: renewLease - Failed to renew lease, retrying")
+		case err != nil || !held:
+			lost()
+			return
+		default:
+			expiresAt = time.Now().Add(ttl)
+		}
+	}
+}
+
+// checkFence returns ErrLeaseLost if ctx belongs to a leased run whose lease has since changed hands. Runs
+// call it before writing their results so a replica that lost its lease stops early. Leaderboard writes are also
+// fenced in Redis with fenceToken, as the lease can change hands between the check and the write.
+func (ls *LeaderboardService) checkFence(ctx context.Context) error {
+	f, ok := ctx.Value(fenceKey{}).(fence)
+	if !ok {
+		return nil
+	}
+
+	lease, err := ls.redisClient.GetLease(ctx, f.job)
+	if errors.Is(err, store.ErrCacheMiss) {
+		return fmt.Errorf("svc: checkFence - %s: %w", f.job, ErrLeaseLost)
+	} else if err != nil {
+		return fmt.Errorf("svc: checkFence - failed to read lease of %s: %w", f.job, err)
+	}
+	if lease.Holder != ls.replicaID || lease.Token != f.token {
+		return fmt.Errorf("svc: checkFence - %s is held by %s with token %d: %w", f.job, lease.Holder, lease.Token, ErrLeaseLost)
+	}
+	return nil
+}
+
+// fenceToken returns the fencing token of the leased run ctx belongs to, or 0 outside leased runs.
+func fenceToken(ctx context.Context) int64 {
+	f, _ := ctx.Value(fenceKey{}).(fence)
+	return f.token
+}
+
+// fencedWriteError returns ErrLeaseLost for a write rejected by its fence, and err otherwise.
+func fencedWriteError(ctx context.Context, err error) error {
+	if errors.Is(err, store.ErrStaleFence) {
+		f, _ := ctx.Value(fenceKey{}).(fence)
+		return fmt.Errorf("svc: fencedWriteError - %s was taken over before token %d wrote: %w", f.job, f.token, ErrLeaseLost)
+	}
+	return err
+}
+
+// releaseLeases frees the leases held by this replica so other replicas take over its jobs without waiting for
+// them to expire.
+func (ls *LeaderboardService) releaseLeases(ctx context.Context) error {
+	var errs []error
+	for _, job := range ls.jobs {
+		if err := ls.redisClient.ReleaseLease(ctx, job.Name, ls.replicaID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetLeases returns the lease state of every scheduled job, free leases included.
+func (ls *LeaderboardService) GetLeases(ctx context.Context) ([]*model.Lease, error) {
+	leases := make([]*model.Lease, 0, len(ls.jobs))
+	for _, job := range ls.jobs {
+		lease, err := ls.redisClient.GetLease(ctx, job.Name)
+		if errors.Is(err, store.ErrCacheMiss) {
+			lease = &model.Lease{Job: job.Name}
+		} else if err != nil {
+			ls.log(ctx).WithError(err).WithField("job", job.Name).Error("svc: GetLeases - Failed to get lease from Redis")
+			return nil, fmt.Errorf("svc: GetLeases - failed to get lease of %s: %w", job.Name, err)
+		}
+		lease.Local = lease.Holder == ls.replicaID
+		leases = append(leases, lease)
+	}
+	return leases, nil
+}
+
+// ReplicaID returns the identifier this replica holds leases under.
+func (ls *LeaderboardService) ReplicaID() string {
+	return ls.replicaID
+}
+
+// LeasesEnabled reports whether scheduled jobs only run on the replica holding their lease.
+func (ls *LeaderboardService) LeasesEnabled() bool {
+	return ls.leasesEnabled
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestRefreshDoesNotOverwriteNewerLeaseHolder(t *testing.T) {
+	const job = "leaderboard:squad-fpp"
+	ctx := context.Background()
+
+	pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a", "b"))
+	ls, _ := newTestService(t, pubg)
+
+	token, acquired, err := ls.redisClient.AcquireLease(ctx, job, ls.replicaID, time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("AcquireLease: acquired=%t err=%v", acquired, err)
+	}
+	run := context.WithValue(ctx, fenceKey{}, fence{job: job, token: token})
+	if err := ls.RefreshGameMode(run, "squad-fpp"); err != nil {
+		t.Fatalf("RefreshGameMode: %v", err)
+	}
+
+	// The lease changes hands after this replica checked it, and the new holder writes its leaderboard first.
+	if err := ls.redisClient.Client.Set(ctx, "{leaderboard}:fence", token+1, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	pubg.SetLeaderboard(testutil.Leaderboard("squad-fpp", "b", "a"))
+
+	if err := ls.RefreshGameMode(run, "squad-fpp"); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("RefreshGameMode returned %v, want %v", err, ErrLeaseLost)
+	}
+	leaderboard, err := ls.redisClient.GetLeaderboard(ctx, "squad-fpp")
+	if err != nil {
+		t.Fatalf("GetLeaderboard: %v", err)
+	}
+	if leaderboard.Included[0].ID != "a" {
+		t.Error("the replica that lost its lease overwrote the leaderboard")
+	}
+}
+
+func TestScheduledAnnouncementsAreLeased(t *testing.T) {
+	ctx := context.Background()
+
+	pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a", "b"))
+	ls, cfg := newTestService(t, pubg, "REFRESH_LEASES=true",
+		`NOTIFIER_CHANNELS=[{"name":"community","format":"discord","url":"http://127.0.0.1:1","interval":"1h"},{"name":"events","format":"slack","url":"http://127.0.0.1:1","events":["season.new"]}]`,
+	)
+	if _, err := NewNotifier(cfg, ls, testutil.Logger()); err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+
+	// Only channels with an interval get a job, run by the replica holding its lease like the refreshes.
+	var announceJobs []string
+	for _, job := range ls.jobs {
+		if strings.HasPrefix(job.Name, "announce:") {
+			announceJobs = append(announceJobs, job.Name)
+		}
+	}
+	if len(announceJobs) != 1 || announceJobs[0] != "announce:community" {
+		t.Fatalf("got announcement jobs %v, want [announce:community]", announceJobs)
+	}
+
+	// There is a report to announce, and posting it to the unreachable channel fails.
+	if err := ls.RefreshGameMode(ctx, "squad-fpp"); err != nil {
+		t.Fatalf("RefreshGameMode: %v", err)
+	}
+	if _, acquired, err := ls.redisClient.AcquireLease(ctx, "announce:community", "other-replica", time.Minute); err != nil || !acquired {
+		t.Fatalf("AcquireLease: acquired=%t err=%v", acquired, err)
+	}
+	for _, job := range ls.jobs {
+		if job.Name != "announce:community" {
+			continue
+		}
+		if err := job.Run(ctx); err != nil {
+			t.Errorf("Run returned %v, want the run skipped while another replica holds the lease", err)
+		}
+		if err := ls.redisClient.ReleaseLease(ctx, job.Name, "other-replica"); err != nil {
+			t.Fatalf("ReleaseLease: %v", err)
+		}
+		if err := job.Run(ctx); err == nil {
+			t.Error("Run returned no error, want the post to the unreachable channel to fail once the lease is free")
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+// stopWithin stops a service, failing the test unless it stopped cleanly within the timeout, and returns how
+// long it took.
+func stopWithin(t *testing.T, stop func(ctx context.Context) error, timeout time.Duration) time.Duration {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	return time.Since(start)
+}
+
+// waitFor polls condition until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// hangingReceiver counts the requests it receives and answers none of them until their client gives up.
+type hangingReceiver struct {
+	*httptest.Server
+	requests  atomic.Int32
+	cancelled chan struct{}
+}
+
+func newHangingReceiver(t *testing.T) *hangingReceiver {
+	receiver := &hangingReceiver{cancelled: make(chan struct{}, 16)}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receiver.requests.Add(1)
+		// The server only notices the client going away once the body was read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+		receiver.cancelled <- struct{}{}
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func TestLeaderboardServiceStopsScheduledJobs(t *testing.T) {
+	verifyNoLeaks(t)
+
+	pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a", "b"), testutil.Leaderboard("solo-fpp", "c"))
+	ls, _ := newTestService(t, pubg,
+		"GAME_MODES=squad-fpp,solo-fpp",
+		"REFRESH_LEASES=true",
+		`REFRESH_SCHEDULES={"season":{"schedule":"10ms","runOnStart":true},"leaderboard":{"schedule":"10ms","runOnStart":true}}`,
+	)
+
+	if err := ls.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// Several runs of every job, each holding and renewing its lease.
+	waitFor(t, "refreshes", func() bool { return pubg.Requests() >= 6 })
+
+	stopWithin(t, ls.Stop, 5*time.Second)
+
+	requests := pubg.Requests()
+	time.Sleep(50 * time.Millisecond)
+	if pubg.Requests() != requests {
+		t.Error("jobs kept running after Stop")
+	}
+}
+
+func TestLeaderboardServiceStopCancelsRefreshInFlight(t *testing.T) {
+	verifyNoLeaks(t)
+
+	pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a"))
+	pubg.SetHang(true)
+	ls, _ := newTestService(t, pubg,
+		"PUBG_REQUEST_TIMEOUT=1m",
+		`REFRESH_SCHEDULES={"season":{"schedule":"1h","runOnStart":false},"leaderboard":{"schedule":"1h","runOnStart":true}}`,
+	)
+
+	if err := ls.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitFor(t, "the refresh to reach the PUBG API", func() bool { return pubg.Requests() == 1 })
+
+	// The refresh would wait for the PUBG API for a minute, Stop cancels it instead.
+	if elapsed := stopWithin(t, ls.Stop, 5*time.Second); elapsed > time.Second {
+		t.Errorf("Stop took %v, the refresh in flight was not cancelled", elapsed)
+	}
+	select {
+	case <-pubg.Cancelled():
+	case <-time.After(time.Second):
+		t.Error("the PUBG API request was not cancelled")
+	}
+}
+
+func TestLeaderboardServiceStopGivesUpWhenContextEnds(t *testing.T) {
+	verifyNoLeaks(t)
+
+	pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a"))
+	ls, _ := newTestService(t, pubg)
+
+	// Background work that ignores cancellation, such as a stuck Redis call, cannot hold Stop past its context.
+	release := make(chan struct{})
+	ls.background.Go(func(ctx context.Context) { <-release })
+	if err := ls.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := ls.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Stop returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+}
+
+func TestNotifierStopsAnnouncements(t *testing.T) {
+	verifyNoLeaks(t)
+
+	receiver := newHangingReceiver(t)
+	pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a", "b"))
+	ls, cfg := newTestService(t, pubg,
+		`NOTIFIER_CHANNELS=[{"name":"community","format":"discord","url":"`+receiver.URL+`","interval":"10ms","events":["leaderboard.refreshed"]}]`,
+	)
+
+	notifier, err := NewNotifier(cfg, ls, testutil.Logger())
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	ls.OnEvent(notifier.HandleEvent)
+	if err := notifier.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := ls.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// The refresh is announced as it happens, then on schedule, and the receiver holds every post.
+	if err := ls.RefreshGameMode(context.Background(), "squad-fpp"); err != nil {
+		t.Fatalf("RefreshGameMode: %v", err)
+	}
+	waitFor(t, "announcements", func() bool { return receiver.requests.Load() >= 2 })
+
+	// Scheduled announcements run with the jobs of the leaderboard service, posts of events with the notifier.
+	if elapsed := stopWithin(t, ls.Stop, 5*time.Second); elapsed > time.Second {
+		t.Errorf("Stop took %v, the scheduled post in flight was not cancelled", elapsed)
+	}
+	if elapsed := stopWithin(t, notifier.Stop, 5*time.Second); elapsed > time.Second {
+		t.Errorf("Stop took %v, the posts in flight were not cancelled", elapsed)
+	}
+	for i := int32(0); i < receiver.requests.Load(); i++ {
+		<-receiver.cancelled
+	}
+}
+
+func TestWebhookServiceStopCancelsDeliveries(t *testing.T) {
+	verifyNoLeaks(t)
+
+	receiver := newHangingReceiver(t)
+	pubg := testutil.NewFakePUBG(t)
+	ls, cfg := newTestService(t, pubg, "WEBHOOK_TIMEOUT=1m")
+	webhooks := NewWebhookService(cfg, ls.redisClient, testutil.Logger())
+	if err := webhooks.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	webhook, err := webhooks.CreateWebhook(context.Background(), WebhookInput{URL: receiver.URL})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	webhooks.HandleEvent(context.Background(), model.Event{ID: "event", Type: model.EventNewSeason, Time: time.Now().UTC()})
+	waitFor(t, "the delivery", func() bool { return receiver.requests.Load() == 1 })
+
+	if elapsed := stopWithin(t, webhooks.Stop, 5*time.Second); elapsed > time.Second {
+		t.Errorf("Stop took %v, the delivery in flight was not cancelled", elapsed)
+	}
+	<-receiver.cancelled
+
+	// Cancelled deliveries are still recorded, without further retries.
+	deliveries, err := webhooks.ListDeliveries(context.Background(), webhook.ID)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Success || deliveries[0].Attempts != 1 {
+		t.Errorf("got deliveries %+v, want one failed attempt", deliveries)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/metrics"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+)
+
+const (
+	// liveBufferSize is how many diffs a slow live subscriber may lag behind before its stream is ended.
+	liveBufferSize = 16
+	// liveResubscribeDelay is how long the fan-out waits before resubscribing after losing Redis.
+	liveResubscribeDelay = 5 * time.Second
+)
+
+// LiveScope restricts which leaderboard changes a live subscriber receives. Zero values match everything.
+type LiveScope struct {
+	GameMode  string   `json:"gameMode,omitempty"`
+	ShardID   string   `json:"shardId,omitempty"`
+	PlayerIDs []string `json:"playerIds,omitempty"` // Only changes concerning these players
+}
+
+// filter returns the part of the diff within the scope, or nil if nothing in it is.
+func (s LiveScope) filter(diff *model.LeaderboardDiff) *model.LeaderboardDiff {
+	if s.GameMode != "" && s.GameMode != diff.GameMode {
+		return nil
+	}
+	if s.ShardID != "" && s.ShardID != diff.ShardID {
+		return nil
+	}
+	if len(s.PlayerIDs) == 0 {
+		return diff
+	}
+
+	watched := make(map[string]bool, len(s.PlayerIDs))
+	for _, id := range s.PlayerIDs {
+		watched[id] = true
+	}
+
+	scoped := *diff
+	scoped.RankChanges = []model.RankChange{}
+	scoped.NewEntries = []model.PlayerEntry{}
+	scoped.Dropped = []model.PlayerEntry{}
+	for _, change := range diff.RankChanges {
+		if watched[change.ID] {
+			scoped.RankChanges = append(scoped.RankChanges, change)
+		}
+	}
+	for _, entry := range diff.NewEntries {
+		if watched[entry.ID] {
+			scoped.NewEntries = append(scoped.NewEntries, entry)
+		}
+	}
+	for _, entry := range diff.Dropped {
+		if watched[entry.ID] {
+			scoped.Dropped = append(scoped.Dropped, entry)
+		}
+	}
+
+	if scoped.Empty() {
+		return nil
+	}
+	return &scoped
+}
+
+// liveSubscriber is a local client of the live updates.
+type liveSubscriber struct {
+	scope   LiveScope
+	updates chan *model.LeaderboardDiff
+	lagged  bool // Set, and updates closed, once the subscriber missed a diff; only touched by broadcast
+}
+
+// liveHub fans leaderboard diffs received from Redis out to the subscribers connected to this replica.
+type liveHub struct {
+	mu          sync.RWMutex
+	subscribers map[*liveSubscriber]struct{}
+}
+
+func newLiveHub() *liveHub {
+	return &liveHub{subscribers: make(map[*liveSubscriber]struct{})}
+}
+
+// broadcast delivers the diff to every subscriber whose scope it concerns, without waiting on slow subscribers.
+// A subscriber whose buffer is full would miss the diff, after which the diffs it receives no longer add up to
+// the leaderboard, so its updates are closed instead to have it resync.
+func (h *liveHub) broadcast(diff *model.LeaderboardDiff) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscriber := range h.subscribers {
+		if subscriber.lagged {
+			continue
+		}
+		scoped := subscriber.scope.filter(diff)
+		if scoped == nil {
+			continue
+		}
+		select {
+		case subscriber.updates <- scoped:
+		default:
+			subscriber.lagged = true
+			close(subscriber.updates)
+			metrics.LiveSubscribersLagged.Inc()
+		}
+	}
+}
+
+// SubscribeLive registers a live subscriber for leaderboard changes within the scope. The returned function
+// unsubscribes and must be called once the subscriber goes away. The updates are closed if the subscriber falls
+// more than liveBufferSize diffs behind: it must then reload the leaderboard before following its diffs again.
+func (ls *LeaderboardService) SubscribeLive(scope LiveScope) (<-chan *model.LeaderboardDiff, func()) {
+	subscriber := &liveSubscriber{
+		scope:   scope,
+		updates: make(chan *model.LeaderboardDiff, liveBufferSize),
+	}
+
+	ls.live.mu.Lock()
+	ls.live.subscribers[subscriber] = struct{}{}
+	ls.live.mu.Unlock()
+
+	var once sync.Once
+	return subscriber.updates, func() {
+		once.Do(func() {
+			ls.live.mu.Lock()
+			delete(ls.live.subscribers, subscriber)
+			ls.live.mu.Unlock()
+		})
+	}
+}
+
+// startLiveFanOut relays the leaderboard diffs published by any replica to the local live subscribers,
+// resubscribing whenever the Redis subscription is lost, until ctx is done.
+func (ls *LeaderboardService) startLiveFanOut(ctx context.Context) {
+	for {
+		err := ls.redisClient.SubscribeLeaderboardDiffs(ctx, ls.live.broadcast)
+		if ctx.Err() != nil {
+			return
+		}
+		ls.log(ctx).WithError(err).Warn("svc: startLiveFanOut - Lost leaderboard updates subscription, resubscribing")
+		if sleepContext(ctx, liveResubscribeDelay) != nil {
+			return
+		}
+	}
+}
+
+// publishDiff computes what changed between two versions of a leaderboard and publishes it to every replica.
+func (ls *LeaderboardService) publishDiff(ctx context.Context, gameMode, seasonID string, previous, current *model.LeaderboardResponse) {
+	diff := DiffLeaderboards(previous, current)
+	diff.GameMode = gameMode
+	diff.SeasonID = seasonID
+	if diff.Empty() {
+		return
+	}
+
+	if err := ls.redisClient.PublishLeaderboardDiff(ctx, diff); err != nil {
+		ls.log(ctx).WithError(err).WithField("gameMode", gameMode).Warn("svc: publishDiff - Failed to publish leaderboard diff")
+	}
+}
+
+// DiffLeaderboards computes the rank changes, new entries and dropped players between two leaderboards.
+func DiffLeaderboards(previous, current *model.LeaderboardResponse) *model.LeaderboardDiff {
+	diff := &model.LeaderboardDiff{
+		ShardID:     current.Data.Attributes.ShardId,
+		SeasonID:    current.Data.Attributes.SeasonId,
+		GeneratedAt: time.Now().UTC(),
+		RankChanges: []model.RankChange{},
+		NewEntries:  []model.PlayerEntry{},
+		Dropped:     []model.PlayerEntry{},
+	}
+
+	previousRanks := make(map[string]model.PlayerData, len(previous.Included))
+	for _, player := range previous.Included {
+		previousRanks[player.ID] = player
+	}
+
+	seen := make(map[string]bool, len(current.Included))
+	for _, player := range current.Included {
+		seen[player.ID] = true
+		before, ok := previousRanks[player.ID]
+		switch {
+		case !ok:
+			diff.NewEntries = append(diff.NewEntries, model.PlayerEntry{ID: player.ID, Name: player.Attributes.Name, Rank: player.Attributes.Rank})
+		case before.Attributes.Rank != player.Attributes.Rank:
+			diff.RankChanges = append(diff.RankChanges, model.RankChange{
+				ID:      player.ID,
+				Name:    player.Attributes.Name,
+				OldRank: before.Attributes.Rank,
+				NewRank: player.Attributes.Rank,
+			})
+		}
+	}
+
+	for _, player := range previous.Included {
+		if !seen[player.ID] {
+			diff.Dropped = append(diff.Dropped, model.PlayerEntry{ID: player.ID, Name: player.Attributes.Name, Rank: player.Attributes.Rank})
+		}
+	}
+
+	return diff
+}
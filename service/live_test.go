@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestLiveBroadcastEndsLaggingSubscribers(t *testing.T) {
+	tests := []struct {
+		name      string
+		diffs     int
+		wantDiffs int
+		wantEnded bool
+	}{
+		{name: "within the buffer", diffs: liveBufferSize, wantDiffs: liveBufferSize},
+		{name: "past the buffer", diffs: liveBufferSize + 1, wantDiffs: liveBufferSize, wantEnded: true},
+		{name: "nothing is sent once ended", diffs: 2 * liveBufferSize, wantDiffs: liveBufferSize, wantEnded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := &LeaderboardService{live: newLiveHub()}
+			updates, unsubscribe := ls.SubscribeLive(LiveScope{GameMode: "squad-fpp"})
+			defer unsubscribe()
+			other, unsubscribeOther := ls.SubscribeLive(LiveScope{GameMode: "solo-fpp"})
+			defer unsubscribeOther()
+
+			for i := 0; i < tt.diffs; i++ {
+				ls.live.broadcast(&model.LeaderboardDiff{
+					GameMode:   "squad-fpp",
+					NewEntries: []model.PlayerEntry{{ID: fmt.Sprint(i), Rank: 1}},
+				})
+			}
+
+			var received int
+			ended := false
+		drain:
+			for !ended {
+				select {
+				case _, ok := <-updates:
+					if ok {
+						received++
+					} else {
+						ended = true
+					}
+				default:
+					break drain
+				}
+			}
+			if received != tt.wantDiffs || ended != tt.wantEnded {
+				t.Errorf("got %d diffs and ended=%t, want %d diffs and ended=%t", received, ended, tt.wantDiffs, tt.wantEnded)
+			}
+
+			// Subscribers the diffs do not concern are unaffected.
+			select {
+			case <-other:
+				t.Error("a subscriber of another game mode received a diff or was ended")
+			default:
+			}
+		})
+	}
+}
+
+func TestStoringLeaderboardPublishesDiff(t *testing.T) {
+	const bucket = "pubg-leaderboard"
+
+	tests := []struct {
+		name  string
+		store func(t *testing.T, ls *LeaderboardService, minio *testutil.FakeMinio) error
+	}{
+		{
+			name: "fetch on the request path",
+			store: func(t *testing.T, ls *LeaderboardService, _ *testutil.FakeMinio) error {
+				_, err := ls.fetchLeaderboard(context.Background(), testutil.Season, "squad-fpp")
+				return err
+			},
+		},
+		{
+			name: "restore from a backup",
+			store: func(t *testing.T, ls *LeaderboardService, minio *testutil.FakeMinio) error {
+				data, err := json.Marshal(testutil.Leaderboard("squad-fpp", "b", "a"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				minio.Put(bucket, "backups/squad-fpp/20240101T000000.000Z-leaderboard-backup.json", data)
+				return ls.RestoreLeaderboardData(context.Background(), "backups/squad-fpp/20240101T000000.000Z-leaderboard-backup.json")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			minio := testutil.NewFakeMinio(t)
+			pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "b", "a"))
+			ls, _ := newTestService(t, pubg, "MINIO_ENDPOINT="+minio.Endpoint())
+			if err := ls.redisClient.UpdateLeaderboard(ctx, "squad-fpp", testutil.Leaderboard("squad-fpp", "a", "b"), 0, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			diffs := make(chan *model.LeaderboardDiff, 1)
+			subscribed := make(chan struct{})
+			go func() {
+				defer close(subscribed)
+				_ = ls.redisClient.SubscribeLeaderboardDiffs(ctx, func(diff *model.LeaderboardDiff) { diffs <- diff })
+			}()
+			waitFor(t, "the diff subscription", func() bool {
+				subscribers, err := ls.redisClient.Client.PubSubNumSub(ctx, "leaderboard_updates").Result()
+				return err == nil && subscribers["leaderboard_updates"] == 1
+			})
+
+			if err := tt.store(t, ls, minio); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case diff := <-diffs:
+				if diff.GameMode != "squad-fpp" || len(diff.RankChanges) != 2 {
+					t.Errorf("got diff %+v, want the two players swapping places", diff)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no diff was published")
+			}
+
+			cancel()
+			<-subscribed
+		})
+	}
+}
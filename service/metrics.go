@@ -0,0 +1,37 @@
+package service
+
+import (
+	"io"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/metrics"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+)
+
+// observeRefresh records a leaderboard refresh of a game mode in the metrics.
+func (ls *LeaderboardService) observeRefresh(gameMode string, duration time.Duration, err error) {
+	shard := ls.pubgClient.Shard()
+	metrics.Refreshes.WithLabelValues(gameMode, shard, metrics.Result(err)).Inc()
+	metrics.RefreshDuration.WithLabelValues(gameMode, shard).Observe(duration.Seconds())
+	if err == nil {
+		metrics.RefreshLastSuccess.WithLabelValues(gameMode, shard).SetToCurrentTime()
+	}
+}
+
+// observePlayers records the number of players on the leaderboard of a game mode just stored.
+func (ls *LeaderboardService) observePlayers(gameMode string, leaderboard *model.LeaderboardResponse) {
+	metrics.LeaderboardPlayers.WithLabelValues(gameMode, ls.pubgClient.Shard()).Set(float64(len(leaderboard.Included)))
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader and counts the bytes read.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
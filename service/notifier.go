@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/config"
+	"github.com/gbasileGP/pubg-leaderboard/internal/lifecycle"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/scheduler"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultAnnouncementTemplates are the text/template sources of the announcement parts. Report parts are
+// executed with a model.RefreshReport, the event part with a model.Event. Channels override them by name.
+var defaultAnnouncementTemplates = map[string]string{
+	"title":            `{{.GameMode}} leaderboard update`,
+	"description":      ``,
+	"topTitle":         `Top {{len .Top}}`,
+	"top":              "{{range .Top}}`#{{.Rank}}` {{.Name}} · {{printf \"%.0f\" .RankPoints}} RP\n{{end}}",
+	"moversTitle":      `Biggest movers`,
+	"movers":           "{{range limit 5 .Movers}}{{if gt .Change 0}}▲{{else}}▼{{end}} {{.Name}} `#{{.OldRank}}` → `#{{.Rank}}`\n{{end}}",
+	"newEntrantsTitle": `New entrants`,
+	"newEntrants":      "{{range limit 5 .NewEntrants}}`#{{.Rank}}` {{.Name}}\n{{end}}",
+	"footer":           `Season {{.SeasonID}}{{with .ShardID}} · {{.}}{{end}}`,
+	"event": `{{if eq .Type "player.entered_top100"}}{{.Data.Name}} entered the {{.GameMode}} top 100 at #{{.Data.Rank}}` +
+		`{{else if eq .Type "player.tier_changed"}}{{.Data.Name}} moved from {{.Data.OldTier}} {{.Data.OldSubTier}} to {{.Data.Tier}} {{.Data.SubTier}} in {{.GameMode}}` +
+		`{{else if eq .Type "season.new"}}Season {{.Data.SeasonID}} has started` +
+		`{{else if eq .Type "season.archived"}}The final leaderboards of season {{.Data.SeasonID}} were archived` +
+		`{{else if eq .Type "refresh.failed"}}Refreshing the {{.GameMode}} leaderboard failed {{.Data.ConsecutiveFailures}} times in a row` +
+		`{{else if eq .Type "watchlist.player_moved"}}{{.Data.Player.Name}} ({{.Data.WatchlistName}}) moved from #{{.Data.Player.OldRank}} to #{{.Data.Player.Rank}} in {{.GameMode}}` +
+		`{{else}}{{.Type}}{{end}}`,
+}
+
+// announcementFuncs are the functions available to announcement templates.
+var announcementFuncs = template.FuncMap{
+	// limit keeps the first n items of a slice of report entries.
+	"limit": func(n int, items interface{}) interface{} {
+		switch items := items.(type) {
+		case []model.ReportPlayer:
+			if len(items) > n {
+				return items[:n]
+			}
+		case []model.ReportMover:
+			if len(items) > n {
+				return items[:n]
+			}
+		}
+		return items
+	},
+}
+
+// notifierChannel is a configured chat channel with its parsed templates.
+type notifierChannel struct {
+	config.NotifierChannel
+	templates map[string]*template.Template
+}
+
+// render executes one of the channel's templates and trims the result.
+func (ch *notifierChannel) render(part string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := ch.templates[part].Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("svc: render - template %q of channel %q: %w", part, ch.Name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// wants reports whether the channel announces the event.
+func (ch *notifierChannel) wants(event model.Event) bool {
+	if ch.GameMode != "" && event.GameMode != "" && ch.GameMode != event.GameMode {
+		return false
+	}
+	for _, eventType := range ch.Events {
+		if eventType == string(event.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifier posts leaderboard announcements to Discord and Slack incoming webhooks, on a schedule and when
+// events happen.
+type Notifier struct {
+	leaderboardService *LeaderboardService
+	httpClient         *resty.Client
+	logger             *logrus.Logger
+	channels           []*notifierChannel
+	background         *lifecycle.Group // Posts of events in flight
+}
+
+// NewNotifier creates a notifier for the configured channels. The scheduled announcements of the channels that
+// have an interval are added to the jobs of the leaderboard service as announce:<channel>, so with leases
+// enabled a single replica posts them.
+func NewNotifier(cfg *config.Config, leaderboardService *LeaderboardService, logger *logrus.Logger) (*Notifier, error) {
+	notifier := &Notifier{
+		leaderboardService: leaderboardService,
+		httpClient:         resty.New().SetTimeout(cfg.WebhookTimeout),
+		logger:             logger,
+		background:         lifecycle.NewGroup(),
+	}
+
+	for _, channelCfg := range cfg.NotifierChannels {
+		channel, err := newNotifierChannel(channelCfg, leaderboardService)
+		if err != nil {
+			return nil, err
+		}
+		notifier.channels = append(notifier.channels, channel)
+	}
+
+	for _, channel := range notifier.channels {
+		if channel.Interval > 0 {
+			channel := channel
+			leaderboardService.AddJob(scheduler.Job{
+				Name:     "announce:" + channel.Name,
+				Schedule: scheduler.Every(channel.Interval),
+				Run: func(ctx context.Context) error {
+					return notifier.announceLatestReport(ctx, channel)
+				},
+			})
+		}
+	}
+
+	return notifier, nil
+}
+
+// Start does nothing, as the scheduled announcements run with the jobs of the leaderboard service and posts of
+// events start as the events happen.
+func (n *Notifier) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop cancels the posts of events in flight and waits for them to return.
+func (n *Notifier) Stop(ctx context.Context) error {
+	return n.background.Stop(ctx)
+}
+
+// newNotifierChannel validates a channel's game mode and events and parses its templates over the defaults.
+func newNotifierChannel(channelCfg config.NotifierChannel, leaderboardService *LeaderboardService) (*notifierChannel, error) {
+	if channelCfg.GameMode != "" {
+		if err := leaderboardService.checkGameMode(channelCfg.GameMode); err != nil {
+			return nil, fmt.Errorf("svc: NewNotifier - channel %q: %w", channelCfg.Name, err)
+		}
+	}
+
+	for _, eventType := range channelCfg.Events {
+		known := false
+		for _, candidate := range model.EventTypes {
+			if string(candidate) == eventType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("svc: NewNotifier - channel %q: unknown event type %q", channelCfg.Name, eventType)
+		}
+	}
+
+	for part := range channelCfg.Templates {
+		if _, ok := defaultAnnouncementTemplates[part]; !ok {
+			return nil, fmt.Errorf("svc: NewNotifier - channel %q: unknown template %q", channelCfg.Name, part)
+		}
+	}
+
+	channel := &notifierChannel{NotifierChannel: channelCfg, templates: make(map[string]*template.Template)}
+	for part, source := range defaultAnnouncementTemplates {
+		if override, ok := channelCfg.Templates[part]; ok {
+			source = override
+		}
+		parsed, err := template.New(part).Funcs(announcementFuncs).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("svc: NewNotifier - channel %q: invalid template %q: %w", channelCfg.Name, part, err)
+		}
+		channel.templates[part] = parsed
+	}
+
+	return channel, nil
+}
+
+// announceLatestReport announces the latest refresh report of the channel's game mode. It is the scheduled
+// job of channels that have an interval.
+func (n *Notifier) announceLatestReport(ctx context.Context, channel *notifierChannel) error {
+	gameMode := channel.GameMode
+	if gameMode == "" {
+		gameMode = n.leaderboardService.DefaultGameMode()
+	}
+
+	report, err := n.leaderboardService.GetRefreshReport(ctx, gameMode)
+	if errors.Is(err, store.ErrCacheMiss) {
+		n.logger.WithField("channel", channel.Name).WithField("gameMode", gameMode).Info("svc: announceLatestReport - No recent refresh to announce")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("svc: announceLatestReport - failed to get refresh report: %w", err)
+	}
+
+	if err := n.announceReport(ctx, channel, report); err != nil {
+		return fmt.Errorf("svc: announceLatestReport - failed to post announcement: %w", err)
+	}
+	return nil
+}
+
+// HandleEvent posts the event to every channel that announces it. Refresh events are announced as a full
+// report. Posts run in the background so slow chat services do not hold up the refresh that emitted the
+// event. It is meant to be registered with LeaderboardService.OnEvent.
+func (n *Notifier) HandleEvent(ctx context.Context, event model.Event) {
+	for _, channel := range n.channels {
+		if !channel.wants(event) {
+			continue
+		}
+
+		channel := channel
+		n.background.Go(func(ctx context.Context) {
+			var err error
+			if report, ok := event.Data.(*model.RefreshReport); ok {
+				err = n.announceReport(ctx, channel, report)
+			} else {
+				err = n.announceEvent(ctx, channel, event)
+			}
+			if err != nil {
+				n.logger.WithError(err).WithField("channel", channel.Name).WithField("eventType", event.Type).Error("svc: HandleEvent - Failed to post announcement")
+			}
+		})
+	}
+}
+
+// announceReport renders a refresh report for the channel and posts it.
+func (n *Notifier) announceReport(ctx context.Context, channel *notifierChannel, report *model.RefreshReport) error {
+	parts := make(map[string]string, len(defaultAnnouncementTemplates))
+	for part := range defaultAnnouncementTemplates {
+		if part == "event" {
+			continue
+		}
+		rendered, err := channel.render(part, report)
+		if err != nil {
+			return err
+		}
+		parts[part] = rendered
+	}
+
+	announcement := &Announcement{
+		Title:       parts["title"],
+		Description: parts["description"],
+		Footer:      parts["footer"],
+		Timestamp:   report.GeneratedAt,
+	}
+	for _, section := range []string{"top", "movers", "newEntrants"} {
+		if parts[section] != "" {
+			announcement.Fields = append(announcement.Fields, AnnouncementField{Name: parts[section+"Title"], Value: parts[section]})
+		}
+	}
+
+	return n.post(ctx, channel, announcement)
+}
+
+// announceEvent renders a single event for the channel and posts it.
+func (n *Notifier) announceEvent(ctx context.Context, channel *notifierChannel, event model.Event) error {
+	text, err := channel.render("event", event)
+	if err != nil {
+		return err
+	}
+
+	return n.post(ctx, channel, &Announcement{Description: text, Timestamp: event.Time})
+}
+
+// post sends the announcement to the channel's incoming webhook in the channel's format.
+func (n *Notifier) post(ctx context.Context, channel *notifierChannel, announcement *Announcement) error {
+	var payload interface{}
+	switch channel.Format {
+	case "discord":
+		payload = announcement.DiscordPayload()
+	case "slack":
+		payload = announcement.SlackPayload()
+	default:
+		return fmt.Errorf("svc: post - unsupported format %q", channel.Format)
+	}
+
+	resp, err := n.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(payload).
+		Post(channel.URL)
+	if err != nil {
+		return fmt.Errorf("svc: post - failed to post to channel %q: %w", channel.Name, err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("svc: post - channel %q responded with %s: %s", channel.Name, resp.Status(), resp.String())
+	}
+
+	n.logger.WithField("channel", channel.Name).Info("svc: post - Posted announcement")
+	return nil
+}
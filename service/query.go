@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+)
+
+// SortOrder is the direction a leaderboard query is sorted in.
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// LeaderboardQuery describes the filters and ordering applied to a leaderboard. Zero values disable a filter.
+type LeaderboardQuery struct {
+	Tier     string    // Only players in this tier, e.g. "Master"
+	SubTier  string    // Only players in this sub-tier, e.g. "1"
+	MinGames int       // Only players with at least this many games
+	MinRank  int       // Only players whose rank is at least this number
+	MaxRank  int       // Only players whose rank is at most this number
+	Name     string    // Only players whose name contains this substring, case-insensitively
+	SortBy   string    // Stat to sort by, see SortFields; empty keeps the upstream order
+	Order    SortOrder // Sort direction, ascending when empty
+}
+
+// statValues maps the stat names, as they appear in the JSON representation of PlayerAttribute, to their accessor.
+var statValues = map[string]func(model.PlayerAttribute) float64{
+	"rank":           func(p model.PlayerAttribute) float64 { return float64(p.Rank) },
+	"rankPoints":     func(p model.PlayerAttribute) float64 { return p.Stats.RankPoints },
+	"wins":           func(p model.PlayerAttribute) float64 { return float64(p.Stats.Wins) },
+	"games":          func(p model.PlayerAttribute) float64 { return float64(p.Stats.Games) },
+	"winRatio":       func(p model.PlayerAttribute) float64 { return p.Stats.WinRatio },
+	"averageDamage":  func(p model.PlayerAttribute) float64 { return p.Stats.AverageDamage },
+	"kills":          func(p model.PlayerAttribute) float64 { return float64(p.Stats.Kills) },
+	"killDeathRatio": func(p model.PlayerAttribute) float64 { return p.Stats.KillDeathRatio },
+	"kda":            func(p model.PlayerAttribute) float64 { return p.Stats.Kda },
+	"averageRank":    func(p model.PlayerAttribute) float64 { return p.Stats.AverageRank },
+	"killsPerGame":   func(p model.PlayerAttribute) float64 { return derivedOrZero(p).KillsPerGame },
+	"damagePerGame":  func(p model.PlayerAttribute) float64 { return derivedOrZero(p).DamagePerGame },
+	"top10Rate":      func(p model.PlayerAttribute) float64 { return derivedOrZero(p).Top10Rate },
+	"winRateLower":   func(p model.PlayerAttribute) float64 { return derivedOrZero(p).WinRateLower },
+	"winRateUpper":   func(p model.PlayerAttribute) float64 { return derivedOrZero(p).WinRateUpper },
+	"consistency":    func(p model.PlayerAttribute) float64 { return derivedOrZero(p).Consistency },
+}
+
+// SortFields returns the stat names a leaderboard can be sorted by.
+func SortFields() []string {
+	fields := make([]string, 0, len(statValues))
+	for field := range statValues {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Validate reports whether the query can be applied.
+func (q LeaderboardQuery) Validate() error {
+	if q.SortBy != "" {
+		if _, ok := statValues[q.SortBy]; !ok {
+			return fmt.Errorf("svc: LeaderboardQuery - unknown sort field %q", q.SortBy)
+		}
+	}
+	if q.Order != "" && q.Order != SortAscending && q.Order != SortDescending {
+		return fmt.Errorf("svc: LeaderboardQuery - unknown sort order %q", q.Order)
+	}
+	if q.MinRank > 0 && q.MaxRank > 0 && q.MinRank > q.MaxRank {
+		return fmt.Errorf("svc: LeaderboardQuery - minRank %d is greater than maxRank %d", q.MinRank, q.MaxRank)
+	}
+	return nil
+}
+
+// matches reports whether a player passes every filter of the query.
+func (q LeaderboardQuery) matches(player model.PlayerAttribute) bool {
+	if q.Tier != "" && !strings.EqualFold(player.Stats.Tier, q.Tier) {
+		return false
+	}
+	if q.SubTier != "" && !strings.EqualFold(player.Stats.SubTier, q.SubTier) {
+		return false
+	}
+	if q.MinGames > 0 && player.Stats.Games < q.MinGames {
+		return false
+	}
+	if q.MinRank > 0 && player.Rank < q.MinRank {
+		return false
+	}
+	if q.MaxRank > 0 && player.Rank > q.MaxRank {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(player.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	return true
+}
+
+// ApplyLeaderboardQuery returns a copy of the leaderboard holding only the players matching the query,
+// in the requested order. The player relationships are narrowed to the same players.
+func ApplyLeaderboardQuery(leaderboard *model.LeaderboardResponse, query LeaderboardQuery) (*model.LeaderboardResponse, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	included := make([]model.PlayerData, 0, len(leaderboard.Included))
+	kept := make(map[string]bool, len(leaderboard.Included))
+	for _, player := range leaderboard.Included {
+		if query.matches(player.Attributes) {
+			included = append(included, player)
+			kept[player.ID] = true
+		}
+	}
+
+	if query.SortBy != "" {
+		value := statValues[query.SortBy]
+		sort.SliceStable(included, func(i, j int) bool {
+			a, b := value(included[i].Attributes), value(included[j].Attributes)
+			if a == b {
+				return included[i].Attributes.Rank < included[j].Attributes.Rank
+			}
+			if query.Order == SortDescending {
+				return a > b
+			}
+			return a < b
+		})
+	}
+
+	references := make([]model.PlayerDataReference, 0, len(included))
+	for _, reference := range leaderboard.Data.Relationships.Players.Data {
+		if kept[reference.ID] {
+			references = append(references, reference)
+		}
+	}
+
+	result := *leaderboard
+	result.Included = included
+	result.Data.Relationships.Players.Data = references
+
+	return &result, nil
+}
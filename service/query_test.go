@@ -0,0 +1,104 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+// queryLeaderboard returns a leaderboard of four players, ranked a to d, with distinct tiers, games, kills and
+// names.
+func queryLeaderboard() *model.LeaderboardResponse {
+	leaderboard := testutil.Leaderboard("squad-fpp", "a", "b", "c", "d")
+	players := []struct {
+		name    string
+		tier    string
+		subTier string
+		games   int
+		kills   int
+	}{
+		{"Alpha", "Master", "1", 80, 120},
+		{"Bravo", "Diamond", "1", 20, 300},
+		{"charlie", "Diamond", "2", 60, 300},
+		{"Delta", "Diamond", "1", 40, 90},
+	}
+	for i, player := range players {
+		attributes := &leaderboard.Included[i].Attributes
+		attributes.Name = player.name
+		attributes.Stats.Tier = player.tier
+		attributes.Stats.SubTier = player.subTier
+		attributes.Stats.Games = player.games
+		attributes.Stats.Kills = player.kills
+	}
+	enrichLeaderboard(leaderboard)
+	return leaderboard
+}
+
+func TestApplyLeaderboardQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   LeaderboardQuery
+		want    []string
+		wantErr bool
+	}{
+		{name: "no query keeps every player in order", want: []string{"a", "b", "c", "d"}},
+		{name: "tier, case-insensitively", query: LeaderboardQuery{Tier: "diamond"}, want: []string{"b", "c", "d"}},
+		{name: "tier and sub-tier", query: LeaderboardQuery{Tier: "Diamond", SubTier: "1"}, want: []string{"b", "d"}},
+		{name: "minimum games", query: LeaderboardQuery{MinGames: 50}, want: []string{"a", "c"}},
+		{name: "rank range", query: LeaderboardQuery{MinRank: 2, MaxRank: 3}, want: []string{"b", "c"}},
+		{name: "name substring, case-insensitively", query: LeaderboardQuery{Name: "AR"}, want: []string{"c"}},
+		{name: "no match", query: LeaderboardQuery{Tier: "Bronze"}, want: []string{}},
+		{name: "sort ascending", query: LeaderboardQuery{SortBy: "games"}, want: []string{"b", "d", "c", "a"}},
+		{name: "sort descending", query: LeaderboardQuery{SortBy: "games", Order: SortDescending}, want: []string{"a", "c", "d", "b"}},
+		{name: "ties keep rank order", query: LeaderboardQuery{SortBy: "kills", Order: SortDescending}, want: []string{"b", "c", "a", "d"}},
+		{name: "sort by a derived stat", query: LeaderboardQuery{SortBy: "killsPerGame", Order: SortDescending}, want: []string{"b", "c", "d", "a"}},
+		{name: "filter then sort", query: LeaderboardQuery{Tier: "Diamond", SortBy: "games", Order: SortDescending}, want: []string{"c", "d", "b"}},
+		{name: "unknown sort field", query: LeaderboardQuery{SortBy: "headshots"}, wantErr: true},
+		{name: "unknown order", query: LeaderboardQuery{SortBy: "games", Order: "up"}, wantErr: true},
+		{name: "inverted rank range", query: LeaderboardQuery{MinRank: 3, MaxRank: 2}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaderboard := queryLeaderboard()
+			result, err := ApplyLeaderboardQuery(leaderboard, tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ApplyLeaderboardQuery returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyLeaderboardQuery: %v", err)
+			}
+
+			got := make([]string, 0, len(result.Included))
+			for _, player := range result.Included {
+				got = append(got, player.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got players %v, want %v", got, tt.want)
+			}
+
+			// The relationships are narrowed to the same players.
+			references := make(map[string]bool)
+			for _, reference := range result.Data.Relationships.Players.Data {
+				references[reference.ID] = true
+			}
+			if len(references) != len(tt.want) {
+				t.Errorf("got %d player relationships, want %d", len(references), len(tt.want))
+			}
+			for _, id := range tt.want {
+				if !references[id] {
+					t.Errorf("player %s is missing from the relationships", id)
+				}
+			}
+
+			if len(leaderboard.Included) != 4 || leaderboard.Included[0].ID != "a" {
+				t.Error("the query changed the leaderboard it was applied to")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+)
+
+const (
+	// ReportTopPlayers is the number of best ranked players in a refresh report.
+	ReportTopPlayers = 10
+	// ReportMovers is the number of players with the largest rank changes in a refresh report.
+	ReportMovers = 10
+	// ReportNewEntrants is the number of new entrants in a refresh report.
+	ReportNewEntrants = 10
+)
+
+// reportPlayer converts a leaderboard entry to its standing in a refresh report.
+func reportPlayer(player model.PlayerData) model.ReportPlayer {
+	return model.ReportPlayer{
+		ID:         player.ID,
+		Name:       player.Attributes.Name,
+		Rank:       player.Attributes.Rank,
+		RankPoints: player.Attributes.Stats.RankPoints,
+		Tier:       player.Attributes.Stats.Tier,
+		SubTier:    player.Attributes.Stats.SubTier,
+	}
+}
+
+// BuildRefreshReport summarizes a refresh into its top players, biggest movers and new entrants. Without a
+// previous leaderboard there are no movers or new entrants.
+func BuildRefreshReport(gameMode, seasonID string, previous, current *model.LeaderboardResponse) *model.RefreshReport {
+	report := &model.RefreshReport{
+		GameMode:    gameMode,
+		ShardID:     current.Data.Attributes.ShardId,
+		SeasonID:    seasonID,
+		GeneratedAt: time.Now().UTC(),
+		Top:         []model.ReportPlayer{},
+		Movers:      []model.ReportMover{},
+		NewEntrants: []model.ReportPlayer{},
+	}
+
+	players := make([]model.PlayerData, len(current.Included))
+	copy(players, current.Included)
+	sort.SliceStable(players, func(i, j int) bool { return players[i].Attributes.Rank < players[j].Attributes.Rank })
+
+	for i := 0; i < len(players) && i < ReportTopPlayers; i++ {
+		report.Top = append(report.Top, reportPlayer(players[i]))
+	}
+
+	if previous == nil {
+		return report
+	}
+
+	previousRanks := make(map[string]int, len(previous.Included))
+	for _, player := range previous.Included {
+		previousRanks[player.ID] = player.Attributes.Rank
+	}
+
+	for _, player := range players {
+		oldRank, existed := previousRanks[player.ID]
+		switch {
+		case !existed:
+			report.NewEntrants = append(report.NewEntrants, reportPlayer(player))
+		case oldRank != player.Attributes.Rank:
+			report.Movers = append(report.Movers, model.ReportMover{
+				ReportPlayer: reportPlayer(player),
+				OldRank:      oldRank,
+				Change:       oldRank - player.Attributes.Rank,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Movers, func(i, j int) bool {
+		return absInt(report.Movers[i].Change) > absInt(report.Movers[j].Change)
+	})
+	if len(report.Movers) > ReportMovers {
+		report.Movers = report.Movers[:ReportMovers]
+	}
+	if len(report.NewEntrants) > ReportNewEntrants {
+		report.NewEntrants = report.NewEntrants[:ReportNewEntrants]
+	}
+
+	return report
+}
+
+// refreshReportRetention is how long the report of a refresh is kept at least.
+const refreshReportRetention = 24 * time.Hour
+
+// absInt returns the absolute value of n.
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// publishReport stores the report of a refresh and emits EventLeaderboardRefreshed with it.
+func (ls *LeaderboardService) publishReport(ctx context.Context, gameMode, seasonID string, previous, current *model.LeaderboardResponse) {
+	report := BuildRefreshReport(gameMode, seasonID, previous, current)
+
+	// Reports outlive the leaderboard cache so scheduled announcements still find one after failed refreshes.
+	if err := ls.redisClient.UpdateRefreshReport(ctx, report, max(refreshReportRetention, ls.leaderboardTTL(gameMode))); err != nil {
+		ls.log(ctx).WithError(err).WithField("gameMode", gameMode).Warn("svc: publishReport - Failed to store refresh report in Redis")
+	}
+
+	ls.emit(ctx, model.EventLeaderboardRefreshed, gameMode, report)
+}
+
+// GetRefreshReport retrieves the report of the latest refresh of a game mode, returning store.ErrCacheMiss
+// if the game mode has not been refreshed recently.
+func (ls *LeaderboardService) GetRefreshReport(ctx context.Context, gameMode string) (*model.RefreshReport, error) {
+	if err := ls.checkGameMode(gameMode); err != nil {
+		return nil, err
+	}
+
+	report, err := ls.redisClient.GetRefreshReport(ctx, gameMode)
+	if err != nil {
+		return nil, fmt.Errorf("svc: GetRefreshReport - failed to retrieve refresh report from Redis: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/client"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/tracing"
+	"github.com/minio/minio-go/v7"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrUnknownSeason is returned for season IDs the PUBG API does not list.
+	ErrUnknownSeason = errors.New("unknown season")
+	// ErrSeasonLeaderboardNotFound is returned when no leaderboard is available for a past season.
+	ErrSeasonLeaderboardNotFound = errors.New("season leaderboard not found")
+)
+
+// seasonArchiveObject is the MinIO object holding the final leaderboard of a game mode in a season.
+func seasonArchiveObject(seasonID, shardID, gameMode string) string {
+	return "seasons/" + seasonID + "/" + shardID + "/" + gameMode + ".json"
+}
+
+// isNoSuchKey reports whether a MinIO error means the object does not exist.
+func isNoSuchKey(err error) bool {
+	return minio.ToErrorResponse(err).Code == "NoSuchKey"
+}
+
+// archiveSeason stores the final leaderboard of every game mode of a finished season in MinIO and emits
+// EventSeasonArchived. Leaderboards already archived, for example by another replica, are left alone.
+func (ls *LeaderboardService) archiveSeason(ctx context.Context, seasonID string) {
+	shardID := ls.pubgClient.Shard()
+	data := model.SeasonArchivedEventData{SeasonID: seasonID, ShardID: shardID, GameModes: []string{}}
+
+	for _, gameMode := range ls.gameModes {
+		logger := ls.log(ctx).WithField("seasonID", seasonID).WithField("gameMode", gameMode)
+		if err := ls.archiveSeasonLeaderboard(ctx, seasonID, shardID, gameMode); err != nil {
+			logger.WithError(err).Error("svc: archiveSeason - Failed to archive final leaderboard")
+			data.Failed = append(data.Failed, gameMode)
+			continue
+		}
+		logger.Info("svc: archiveSeason - Archived final leaderboard")
+		data.GameModes = append(data.GameModes, gameMode)
+	}
+
+	ls.emit(ctx, model.EventSeasonArchived, "", data)
+}
+
+// archiveSeasonLeaderboard takes the final snapshot of a game mode's leaderboard in a season and uploads it
+// to MinIO. The snapshot comes from the PUBG API, or from the cache or the latest backup when the API cannot
+// serve it.
+func (ls *LeaderboardService) archiveSeasonLeaderboard(ctx context.Context, seasonID, shardID, gameMode string) error {
+	object := seasonArchiveObject(seasonID, shardID, gameMode)
+	if _, err := ls.minioClient.Client.StatObject(ctx, ls.archiveBucket, object, minio.StatObjectOptions{}); err == nil {
+		return nil
+	} else if !isNoSuchKey(err) {
+		return fmt.Errorf("svc: archiveSeasonLeaderboard - failed to check for an existing archive: %w", err)
+	}
+
+	leaderboard, err := ls.pubgClient.GetSeasonStats(ctx, seasonID, gameMode)
+	if err != nil {
+		ls.log(ctx).WithError(err).WithField("gameMode", gameMode).Warn("svc: archiveSeasonLeaderboard - Failed to fetch final leaderboard from PUBG API, falling back to a snapshot")
+		leaderboard, err = ls.finalSnapshot(ctx, seasonID, gameMode)
+		if err != nil {
+			return fmt.Errorf("svc: archiveSeasonLeaderboard - no final leaderboard to archive: %w", err)
+		}
+	}
+	enrichLeaderboard(leaderboard)
+
+	data, err := json.Marshal(leaderboard)
+	if err != nil {
+		return fmt.Errorf("svc: archiveSeasonLeaderboard - failed to serialize leaderboard: %w", err)
+	}
+
+	_, err = ls.minioClient.Client.PutObject(ctx, ls.archiveBucket, object, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/json",
+	})
+	if err != nil {
+		return fmt.Errorf("svc: archiveSeasonLeaderboard - failed to upload archive to MinIO: %w", err)
+	}
+
+	return nil
+}
+
+// finalSnapshot returns the cached leaderboard of a game mode, or else its latest backup, as the final
+// leaderboard of a season when the PUBG API cannot serve it. Snapshots of another season are refused, as by the
+// time of a rollover the cache may already hold the new season's leaderboard.
+func (ls *LeaderboardService) finalSnapshot(ctx context.Context, seasonID, gameMode string) (*model.LeaderboardResponse, error) {
+	logger := ls.log(ctx).WithField("seasonID", seasonID).WithField("gameMode", gameMode)
+
+	cached, err := ls.redisClient.GetLeaderboard(ctx, gameMode)
+	if err == nil && cached.Data.Attributes.SeasonId == seasonID {
+		logger.Warn("svc: finalSnapshot - Archiving the cached leaderboard")
+		return cached, nil
+	} else if err == nil {
+		logger.WithField("cachedSeasonID", cached.Data.Attributes.SeasonId).Warn("svc: finalSnapshot - Cached leaderboard belongs to another season")
+	}
+
+	backup, err := ls.readLatestBackup(ctx, gameMode)
+	if err != nil {
+		return nil, fmt.Errorf("svc: finalSnapshot - no snapshot of season %s: %w", seasonID, err)
+	}
+	if backup.Data.Attributes.SeasonId != seasonID {
+		logger.WithField("backupSeasonID", backup.Data.Attributes.SeasonId).Warn("svc: finalSnapshot - Latest backup belongs to another season")
+		return nil, fmt.Errorf("svc: finalSnapshot - latest backup belongs to season %s, not %s: %w", backup.Data.Attributes.SeasonId, seasonID, ErrSeasonLeaderboardNotFound)
+	}
+	logger.Warn("svc: finalSnapshot - Archiving the latest backup")
+	backup.Stale = false
+	return backup, nil
+}
+
+// GetSeasons retrieves every season of the shard from Redis or the external API.
+func (ls *LeaderboardService) GetSeasons(ctx context.Context) (seasons []model.SeasonData, err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.GetSeasons")
+	defer func() { tracing.End(span, err) }()
+
+	seasons, err = ls.redisClient.GetSeasons(ctx)
+	if err == nil {
+		return seasons, nil
+	} else if err != store.ErrCacheMiss {
+		ls.log(ctx).WithError(err).Warn("svc: GetSeasons - Failed to retrieve seasons from Redis, fetching from PUBG API")
+	}
+
+	seasons, err = ls.pubgClient.GetSeasons(ctx)
+	if err != nil {
+		wrappedErr := fmt.Errorf("svc: GetSeasons - failed to fetch seasons from PUBG API: %w", err)
+		ls.log(ctx).WithError(wrappedErr).Error("svc: GetSeasons - Failed to fetch seasons from PUBG API")
+		return nil, wrappedErr
+	}
+
+	if err := ls.redisClient.UpdateSeasons(ctx, seasons, ls.seasonsCacheTTL); err != nil {
+		ls.log(ctx).WithError(err).Warn("svc: GetSeasons - Failed to update seasons in Redis")
+	}
+
+	return seasons, nil
+}
+
+// GetSeasonLeaderboard retrieves the leaderboard of a game mode in a season. The current season is served
+// like any other leaderboard. Past seasons come from the Redis cache, then the season archive, then the PUBG
+// API, and are cached on the way back. An empty shard means the one the service queries; past leaderboards of
+// other shards can only be served from archives.
+func (ls *LeaderboardService) GetSeasonLeaderboard(ctx context.Context, seasonID, shardID, gameMode string) (leaderboard *model.LeaderboardResponse, err error) {
+	ctx, span := tracing.Start(ctx, "LeaderboardService.GetSeasonLeaderboard", trace.WithAttributes(attribute.String("season_id", seasonID), attribute.String("game_mode", gameMode)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := ls.checkGameMode(gameMode); err != nil {
+		return nil, err
+	}
+	ownShard := shardID == "" || shardID == ls.pubgClient.Shard()
+	if shardID == "" {
+		shardID = ls.pubgClient.Shard()
+	}
+	logger := ls.log(ctx).WithField("seasonID", seasonID).WithField("shardID", shardID).WithField("gameMode", gameMode)
+
+	if ownShard {
+		seasons, err := ls.GetSeasons(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("svc: GetSeasonLeaderboard - failed to get seasons: %w", err)
+		}
+		var season *model.SeasonData
+		for i := range seasons {
+			if seasons[i].ID == seasonID {
+				season = &seasons[i]
+				break
+			}
+		}
+		if season == nil {
+			return nil, fmt.Errorf("svc: GetSeasonLeaderboard - season %q: %w", seasonID, ErrUnknownSeason)
+		}
+		if season.Attributes.IsCurrentSeason {
+			return ls.GetLeaderboard(ctx, gameMode)
+		}
+	}
+
+	leaderboard, err = ls.redisClient.GetSeasonLeaderboard(ctx, seasonID, shardID, gameMode)
+	if err == nil {
+		ls.logSampler.Info(logger, "svc: GetSeasonLeaderboard - Retrieved season leaderboard from Redis")
+		return leaderboard, nil
+	} else if err != store.ErrCacheMiss {
+		logger.WithError(err).Warn("svc: GetSeasonLeaderboard - Failed to retrieve season leaderboard from Redis")
+	}
+
+	leaderboard, err = ls.readSeasonArchive(ctx, seasonID, shardID, gameMode)
+	if err == nil {
+		logger.Info("svc: GetSeasonLeaderboard - Retrieved season leaderboard from its archive")
+	} else {
+		if !isNoSuchKey(err) {
+			logger.WithError(err).Warn("svc: GetSeasonLeaderboard - Failed to read season archive from MinIO")
+		}
+		if !ownShard {
+			return nil, fmt.Errorf("svc: GetSeasonLeaderboard - season %q of shard %q: %w", seasonID, shardID, ErrSeasonLeaderboardNotFound)
+		}
+
+		leaderboard, err = ls.pubgClient.GetSeasonStats(ctx, seasonID, gameMode)
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, fmt.Errorf("svc: GetSeasonLeaderboard - season %q: %w", seasonID, ErrSeasonLeaderboardNotFound)
+		} else if err != nil {
+			wrappedErr := fmt.Errorf("svc: GetSeasonLeaderboard - failed to fetch season leaderboard from PUBG API: %w", err)
+			logger.WithError(wrappedErr).Error("svc: GetSeasonLeaderboard - Failed to fetch season leaderboard from PUBG API")
+			return nil, wrappedErr
+		}
+		enrichLeaderboard(leaderboard)
+		logger.Info("svc: GetSeasonLeaderboard - Fetched season leaderboard from PUBG API")
+	}
+
+	// Past seasons no longer change, so they are cached for much longer than the current leaderboards.
+	if err := ls.redisClient.UpdateSeasonLeaderboard(ctx, seasonID, shardID, gameMode, leaderboard, ls.seasonLeaderboardCacheTTL); err != nil {
+		logger.WithError(err).Warn("svc: GetSeasonLeaderboard - Failed to cache season leaderboard in Redis")
+	}
+
+	return leaderboard, nil
+}
+
+// readSeasonArchive reads the archived final leaderboard of a game mode in a season from MinIO.
+func (ls *LeaderboardService) readSeasonArchive(ctx context.Context, seasonID, shardID, gameMode string) (*model.LeaderboardResponse, error) {
+	object, err := ls.minioClient.Client.GetObject(ctx, ls.archiveBucket, seasonArchiveObject(seasonID, shardID, gameMode), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	leaderboard := &model.LeaderboardResponse{}
+	if err := json.NewDecoder(object).Decode(leaderboard); err != nil {
+		return nil, err
+	}
+
+	return leaderboard, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestSeasonRolloverIsNoticedAfterRequestsCachedTheNewSeason(t *testing.T) {
+	const previousSeasonID = "division.bro.official.pc-2018-01"
+	ctx := context.Background()
+
+	pubg := testutil.NewFakePUBG(t)
+	ls, _ := newTestService(t, pubg)
+	var rollovers []model.SeasonEventData
+	ls.OnEvent(func(ctx context.Context, event model.Event) {
+		if event.Type == model.EventNewSeason {
+			rollovers = append(rollovers, event.Data.(model.SeasonEventData))
+		}
+	})
+
+	// The season job last saw the previous season, then the cached season expired and a request cached the new one.
+	if err := ls.redisClient.UpdateLastSeenSeason(ctx, previousSeasonID); err != nil {
+		t.Fatalf("UpdateLastSeenSeason: %v", err)
+	}
+	if _, err := ls.GetCurrentSeason(ctx); err != nil {
+		t.Fatalf("GetCurrentSeason: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := ls.RefreshCurrentSeason(ctx); err != nil {
+			t.Fatalf("RefreshCurrentSeason: %v", err)
+		}
+	}
+	if len(rollovers) != 1 || rollovers[0].PreviousSeasonID != previousSeasonID || rollovers[0].SeasonID != testutil.Season {
+		t.Errorf("got rollovers %+v, want one from %s to %s", rollovers, previousSeasonID, testutil.Season)
+	}
+	if seasonID, err := ls.redisClient.GetLastSeenSeason(ctx); err != nil || seasonID != testutil.Season {
+		t.Errorf("GetLastSeenSeason returned %q, %v, want %q", seasonID, err, testutil.Season)
+	}
+}
+
+func TestFinalSnapshotRefusesOtherSeasons(t *testing.T) {
+	tests := []struct {
+		name     string
+		seasonID string
+		wantErr  bool
+	}{
+		{name: "cached leaderboard of the season", seasonID: testutil.Season},
+		// The cache holds the new season and MinIO has no backup, so nothing is archived.
+		{name: "cached leaderboard of the next season", seasonID: "division.bro.official.pc-2018-29", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a", "b"))
+			ls, _ := newTestService(t, pubg)
+			if err := ls.RefreshGameMode(ctx, "squad-fpp"); err != nil {
+				t.Fatalf("RefreshGameMode: %v", err)
+			}
+
+			snapshot, err := ls.finalSnapshot(ctx, tt.seasonID, "squad-fpp")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("finalSnapshot returned the leaderboard of season %s, want an error", snapshot.Data.Attributes.SeasonId)
+				}
+				return
+			}
+			if err != nil || snapshot.Data.Attributes.SeasonId != tt.seasonID {
+				t.Errorf("finalSnapshot returned %v, want the cached leaderboard of season %s", err, tt.seasonID)
+			}
+		})
+	}
+}
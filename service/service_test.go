@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/client"
+	"github.com/gbasileGP/pubg-leaderboard/internal/config"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+	"go.uber.org/goleak"
+)
+
+// newTestService creates a leaderboard service backed by an in-memory Redis and pubg, configured with settings on
+// top of the test defaults, and returns it with its configuration.
+func newTestService(t *testing.T, pubg *testutil.FakePUBG, settings ...string) (*LeaderboardService, *config.Config) {
+	t.Helper()
+
+	cfg := testutil.Config(t, pubg.URL, settings...)
+	logger := testutil.Logger()
+	_, redisClient := testutil.NewRedis(t)
+	minioClient, err := store.NewMinioClient(cfg.MinioEndpoint, cfg.MinioAccessKey, cfg.MinioSecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewLeaderboardService(cfg, redisClient, client.NewPUBGClient(cfg, logger), minioClient, logger), cfg
+}
+
+// verifyNoLeaks fails the test if goroutines started during it are still running once it and its cleanups,
+// which close the fake servers and Redis, are done. It must be called before the fakes are created.
+func verifyNoLeaks(t *testing.T) {
+	ignore := goleak.IgnoreCurrent()
+	t.Cleanup(func() { goleak.VerifyNone(t, ignore) })
+}
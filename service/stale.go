@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/client"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/minio/minio-go/v7"
+)
+
+// ErrNoBackup is returned when a game mode has no backup to fall back to.
+var ErrNoBackup = errors.New("no backup of the game mode")
+
+// revalidationInterval bounds how often a background refresh of a stale leaderboard starts, so an unavailable
+// PUBG API is not called on every request.
+const revalidationInterval = 30 * time.Second
+
+// leaderboardHardTTL returns how long the leaderboard of a game mode stays in Redis: its fresh TTL followed by
+// the time it may be served stale. Leaderboards without a refresh schedule never expire.
+func (ls *LeaderboardService) leaderboardHardTTL(gameMode string) time.Duration {
+	fresh := ls.leaderboardTTL(gameMode)
+	if fresh == 0 {
+		return 0
+	}
+	return fresh + ls.staleTTL
+}
+
+// isFresh reports whether a cached leaderboard is within its fresh TTL. Leaderboards cached without a fetch time
+// and those of game modes without a refresh schedule are always fresh.
+func (ls *LeaderboardService) isFresh(gameMode string, leaderboard *model.LeaderboardResponse) bool {
+	fresh := ls.leaderboardTTL(gameMode)
+	if leaderboard.FetchedAt == nil || fresh == 0 {
+		return true
+	}
+	return time.Since(*leaderboard.FetchedAt) < fresh
+}
+
+// cachedLeaderboard retrieves the leaderboard of a game mode from Redis. A leaderboard past its fresh TTL that a
+// refresh found unchanged upstream since is dated from that check instead of when it was fetched.
+func (ls *LeaderboardService) cachedLeaderboard(ctx context.Context, gameMode string) (*model.LeaderboardResponse, error) {
+	leaderboard, err := ls.redisClient.GetLeaderboard(ctx, gameMode)
+	if err != nil || ls.isFresh(gameMode, leaderboard) {
+		return leaderboard, err
+	}
+
+	checkedAt, err := ls.redisClient.GetLeaderboardCheckedAt(ctx, gameMode)
+	if err != nil {
+		if err != store.ErrCacheMiss {
+			ls.log(ctx).WithError(err).WithField("gameMode", gameMode).Warn("svc: cachedLeaderboard - Failed to read when the leaderboard was last checked")
+		}
+		return leaderboard, nil
+	}
+	if checkedAt.After(*leaderboard.FetchedAt) {
+		leaderboard.FetchedAt = &checkedAt
+	}
+	return leaderboard, nil
+}
+
+// freshLeaderboardFunc returns a lookup of the cached leaderboard of a game mode that treats stale data as a cache
+// miss, so coalesced fetches are not satisfied by the data they replace.
+func (ls *LeaderboardService) freshLeaderboardFunc(gameMode string) func(ctx context.Context) (*model.LeaderboardResponse, error) {
+	return func(ctx context.Context) (*model.LeaderboardResponse, error) {
+		leaderboard, err := ls.cachedLeaderboard(ctx, gameMode)
+		if err != nil {
+			return nil, err
+		}
+		if !ls.isFresh(gameMode, leaderboard) {
+			return nil, store.ErrCacheMiss
+		}
+		return leaderboard, nil
+	}
+}
+
+// fetchLeaderboardFunc returns a fetch of the current season's leaderboard of a game mode from the PUBG API.
+func (ls *LeaderboardService) fetchLeaderboardFunc(gameMode string) func(ctx context.Context) (*model.LeaderboardResponse, error) {
+	return func(ctx context.Context) (*model.LeaderboardResponse, error) {
+		season, err := ls.GetCurrentSeason(ctx)
+		if err != nil {
+			ls.log(ctx).WithError(err).Error("svc: GetLeaderboard - Failed to get current season for leaderboard retrieval")
+			return nil, fmt.Errorf("svc: GetLeaderboard - failed to get current season for leaderboard retrieval: %w", err)
+		}
+		return ls.fetchLeaderboard(ctx, season.ID, gameMode)
+	}
+}
+
+// pubgUnavailable reports whether the circuit breaker of the PUBG API is open, in which case only cached and
+// backed up data is served.
+func (ls *LeaderboardService) pubgUnavailable() bool {
+	return ls.pubgClient.Breaker().State() == client.BreakerOpen
+}
+
+// PUBGBreakerStatus returns a snapshot of the circuit breaker guarding the PUBG API.
+func (ls *LeaderboardService) PUBGBreakerStatus() client.BreakerStatus {
+	return ls.pubgClient.Breaker().Status()
+}
+
+// revalidate refreshes the leaderboard of a game mode in the background, at most once per revalidationInterval.
+// The refresh is coalesced with any other fetch of the leaderboard.
+// No refresh starts while the circuit breaker of the PUBG API is open.
+func (ls *LeaderboardService) revalidate(gameMode string) {
+	if ls.pubgUnavailable() {
+		return
+	}
+
+	ls.revalidateMu.Lock()
+	if time.Since(ls.lastRevalidation[gameMode]) < revalidationInterval {
+		ls.revalidateMu.Unlock()
+		return
+	}
+	ls.lastRevalidation[gameMode] = time.Now()
+	ls.revalidateMu.Unlock()
+
+	ls.background.Go(func(ctx context.Context) {
+		if _, err := coalesceFetch(ctx, ls, leaderboardCacheKey(gameMode), ls.freshLeaderboardFunc(gameMode), ls.fetchLeaderboardFunc(gameMode)); err != nil {
+			ls.log(ctx).WithError(err).WithField("gameMode", gameMode).Warn("svc: revalidate - Failed to refresh stale leaderboard")
+		}
+	})
+}
+
+// latestBackupObject returns the newest backup of a game mode in the backups bucket, or ErrNoBackup if none
+// was taken.
+func (ls *LeaderboardService) latestBackupObject(ctx context.Context, gameMode string) (string, error) {
+	var latest string
+	for object := range ls.minioClient.Client.ListObjects(ctx, ls.archiveBucket, minio.ListObjectsOptions{Prefix: backupPrefix(gameMode)}) {
+		if object.Err != nil {
+			return "", fmt.Errorf("svc: latestBackupObject - failed to list backups in MinIO: %w", object.Err)
+		}
+		if object.Key > latest {
+			latest = object.Key
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("svc: latestBackupObject - %s: %w", gameMode, ErrNoBackup)
+	}
+	return latest, nil
+}
+
+// readLatestBackup reads the newest backup of a game mode in MinIO, marked stale. Without one, it falls back to
+// the single backup object written by earlier versions if it belongs to the game mode.
+func (ls *LeaderboardService) readLatestBackup(ctx context.Context, gameMode string) (*model.LeaderboardResponse, error) {
+	if ls.minioClient == nil {
+		return nil, fmt.Errorf("svc: readLatestBackup - no MinIO client")
+	}
+
+	backupObject, err := ls.latestBackupObject(ctx, gameMode)
+	if errors.Is(err, ErrNoBackup) {
+		backupObject = ls.backupsFile
+	} else if err != nil {
+		return nil, fmt.Errorf("svc: readLatestBackup - %w", err)
+	}
+
+	object, err := ls.minioClient.Client.GetObject(ctx, ls.archiveBucket, backupObject, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("svc: readLatestBackup - failed to get backup from MinIO: %w", err)
+	}
+	defer object.Close()
+
+	leaderboard := &model.LeaderboardResponse{}
+	if err := json.NewDecoder(object).Decode(leaderboard); err != nil {
+		return nil, fmt.Errorf("svc: readLatestBackup - failed to decode backup %s: %w", backupObject, err)
+	}
+
+	// Backups record their game mode; older ones without it belong to the default game mode.
+	backupGameMode := leaderboard.Data.Attributes.GameMode
+	if backupGameMode == "" {
+		backupGameMode = ls.DefaultGameMode()
+	}
+	if backupGameMode != gameMode {
+		return nil, fmt.Errorf("svc: readLatestBackup - %s holds the %s leaderboard: %w", backupObject, backupGameMode, ErrNoBackup)
+	}
+
+	// Backups taken before derived stats existed lack them, so compute them again.
+	enrichLeaderboard(leaderboard)
+	leaderboard.Stale = true
+	return leaderboard, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestIsFresh(t *testing.T) {
+	ls := &LeaderboardService{leaderboardTTLs: map[string]time.Duration{"squad-fpp": 10 * time.Minute}}
+	fetchedAgo := func(age time.Duration) *time.Time {
+		fetchedAt := time.Now().Add(-age)
+		return &fetchedAt
+	}
+
+	tests := []struct {
+		name      string
+		gameMode  string
+		fetchedAt *time.Time
+		want      bool
+	}{
+		{name: "within the fresh TTL", gameMode: "squad-fpp", fetchedAt: fetchedAgo(time.Minute), want: true},
+		{name: "past the fresh TTL", gameMode: "squad-fpp", fetchedAt: fetchedAgo(11 * time.Minute), want: false},
+		{name: "without a fetch time", gameMode: "squad-fpp", want: true},
+		{name: "game mode without a refresh schedule", gameMode: "solo-fpp", fetchedAt: fetchedAgo(24 * time.Hour), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaderboard := testutil.Leaderboard(tt.gameMode, "a")
+			leaderboard.FetchedAt = tt.fetchedAt
+			if got := ls.isFresh(tt.gameMode, leaderboard); got != tt.want {
+				t.Errorf("isFresh = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeaderboardHardTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		freshTTL time.Duration
+		staleTTL time.Duration
+		want     time.Duration
+	}{
+		{name: "fresh TTL followed by the stale TTL", freshTTL: 10 * time.Minute, staleTTL: time.Hour, want: 70 * time.Minute},
+		{name: "stale serving disabled", freshTTL: 10 * time.Minute, want: 10 * time.Minute},
+		{name: "no refresh schedule never expires", staleTTL: time.Hour, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := &LeaderboardService{leaderboardTTLs: map[string]time.Duration{"squad-fpp": tt.freshTTL}, staleTTL: tt.staleTTL}
+			if got := ls.leaderboardHardTTL("squad-fpp"); got != tt.want {
+				t.Errorf("leaderboardHardTTL = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLatestBackup(t *testing.T) {
+	const bucket = "pubg-leaderboard"
+	backup := func(gameMode string, playerIDs ...string) []byte {
+		data, err := json.Marshal(testutil.Leaderboard(gameMode, playerIDs...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name       string
+		gameMode   string
+		objects    map[string][]byte
+		wantPlayer string
+		wantErr    bool
+		wantErrIs  error
+	}{
+		{
+			name:     "newest backup of the game mode",
+			gameMode: "squad-fpp",
+			objects: map[string][]byte{
+				"backups/squad-fpp/20240101T000000.000Z-leaderboard-backup.json": backup("squad-fpp", "old"),
+				"backups/squad-fpp/20240102T000000.000Z-leaderboard-backup.json": backup("squad-fpp", "new"),
+				"backups/solo-fpp/20240103T000000.000Z-leaderboard-backup.json":  backup("solo-fpp", "other"),
+			},
+			wantPlayer: "new",
+		},
+		// Backups of earlier versions were a single object without their game mode.
+		{
+			name:       "legacy backup of the default game mode",
+			gameMode:   "squad-fpp",
+			objects:    map[string][]byte{"leaderboard-backup.json": backup("", "legacy")},
+			wantPlayer: "legacy",
+		},
+		{
+			name:      "legacy backup of another game mode",
+			gameMode:  "solo-fpp",
+			objects:   map[string][]byte{"leaderboard-backup.json": backup("", "legacy")},
+			wantErr:   true,
+			wantErrIs: ErrNoBackup,
+		},
+		{
+			name:     "no backup",
+			gameMode: "squad-fpp",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minio := testutil.NewFakeMinio(t)
+			for key, data := range tt.objects {
+				minio.Put(bucket, key, data)
+			}
+			ls, _ := newTestService(t, testutil.NewFakePUBG(t), "GAME_MODES=squad-fpp,solo-fpp", "MINIO_ENDPOINT="+minio.Endpoint())
+
+			leaderboard, err := ls.readLatestBackup(context.Background(), tt.gameMode)
+			switch {
+			case tt.wantErr:
+				if err == nil || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+					t.Errorf("readLatestBackup returned %v, want an error matching %v", err, tt.wantErrIs)
+				}
+			case err != nil:
+				t.Fatalf("readLatestBackup: %v", err)
+			default:
+				if got := leaderboard.Included[0].ID; got != tt.wantPlayer {
+					t.Errorf("got the backup with player %s, want %s", got, tt.wantPlayer)
+				}
+				if !leaderboard.Stale || leaderboard.Included[0].Attributes.Derived == nil {
+					t.Error("backups are served marked stale, with their derived stats")
+				}
+			}
+		})
+	}
+}
+
+func TestGetLeaderboardFallsBackToLatestBackup(t *testing.T) {
+	const bucket = "pubg-leaderboard"
+
+	tests := []struct {
+		name       string
+		pubgStatus int
+		backup     *model.LeaderboardResponse
+		wantPlayer string
+		wantStale  bool
+		wantErr    bool
+	}{
+		{name: "PUBG API serves the leaderboard", backup: testutil.Leaderboard("squad-fpp", "backup"), wantPlayer: "live"},
+		{name: "PUBG API fails with a backup", pubgStatus: http.StatusServiceUnavailable, backup: testutil.Leaderboard("squad-fpp", "backup"), wantPlayer: "backup", wantStale: true},
+		{name: "PUBG API fails without a backup", pubgStatus: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minio := testutil.NewFakeMinio(t)
+			if tt.backup != nil {
+				data, err := json.Marshal(tt.backup)
+				if err != nil {
+					t.Fatal(err)
+				}
+				minio.Put(bucket, "backups/squad-fpp/20240101T000000.000Z-leaderboard-backup.json", data)
+			}
+			pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "live"))
+			pubg.SetStatus(tt.pubgStatus)
+			ls, _ := newTestService(t, pubg, "MINIO_ENDPOINT="+minio.Endpoint())
+
+			leaderboard, err := ls.GetLeaderboard(context.Background(), "squad-fpp")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("GetLeaderboard returned a leaderboard, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLeaderboard: %v", err)
+			}
+			if leaderboard.Included[0].ID != tt.wantPlayer || leaderboard.Stale != tt.wantStale {
+				t.Errorf("got player %s stale=%t, want player %s stale=%t", leaderboard.Included[0].ID, leaderboard.Stale, tt.wantPlayer, tt.wantStale)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+)
+
+const (
+	// wilsonZ is the z-score of the 95% confidence level used for the win rate interval.
+	wilsonZ = 1.96
+	// consistencyPriorGames is how many games a player needs before half of their top-10 rate counts towards consistency.
+	consistencyPriorGames = 20.0
+)
+
+// derivedOrZero returns the player's derived stats, or zero values for players cached before they were computed.
+func derivedOrZero(p model.PlayerAttribute) model.DerivedStats {
+	if p.Derived == nil {
+		return model.DerivedStats{}
+	}
+	return *p.Derived
+}
+
+// withDerived returns the attributes with derived stats, computing them for players cached without.
+func withDerived(attributes model.PlayerAttribute) model.PlayerAttribute {
+	if attributes.Derived == nil {
+		derived := ComputeDerivedStats(attributes.Stats)
+		attributes.Derived = &derived
+	}
+	return attributes
+}
+
+// ComputeDerivedStats calculates the derived metrics for a player's raw stats.
+func ComputeDerivedStats(stats model.PlayerStats) model.DerivedStats {
+	derived := model.DerivedStats{
+		// PUBG already reports damage as a per-game average; it is repeated here next to kills per game.
+		DamagePerGame: stats.AverageDamage,
+	}
+	if stats.Games <= 0 {
+		return derived
+	}
+
+	games := float64(stats.Games)
+	derived.KillsPerGame = float64(stats.Kills) / games
+
+	// Placements are modelled as geometrically distributed with the player's average placement as mean,
+	// which gives P(placement <= 10) = 1 - (1 - 1/averageRank)^10.
+	if stats.AverageRank >= 1 {
+		derived.Top10Rate = 1 - math.Pow(1-1/stats.AverageRank, 10)
+	}
+
+	derived.WinRateLower, derived.WinRateUpper = wilsonInterval(float64(stats.Wins), games)
+	derived.Consistency = derived.Top10Rate * games / (games + consistencyPriorGames)
+
+	return derived
+}
+
+// wilsonInterval returns the 95% Wilson score interval for successes out of trials.
+func wilsonInterval(successes, trials float64) (float64, float64) {
+	p := successes / trials
+	z2 := wilsonZ * wilsonZ
+	denominator := 1 + z2/trials
+	center := (p + z2/(2*trials)) / denominator
+	margin := wilsonZ * math.Sqrt(p*(1-p)/trials+z2/(4*trials*trials)) / denominator
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// enrichLeaderboard computes the derived stats of every player in place.
+func enrichLeaderboard(leaderboard *model.LeaderboardResponse) {
+	for i := range leaderboard.Included {
+		derived := ComputeDerivedStats(leaderboard.Included[i].Attributes.Stats)
+		leaderboard.Included[i].Attributes.Derived = &derived
+	}
+}
+
+// RankingFormula is an operator-defined weighted combination of stats used to build an alternative leaderboard.
+type RankingFormula struct {
+	Name    string             `json:"name"`
+	Weights map[string]float64 `json:"weights"` // Stat name to weight, applied to the raw stat values
+}
+
+// Score computes the formula's score for a player.
+func (f RankingFormula) Score(player model.PlayerAttribute) float64 {
+	score := 0.0
+	for stat, weight := range f.Weights {
+		score += weight * statValues[stat](player)
+	}
+	return score
+}
+
+// RankedPlayer is a player's position on a leaderboard ranked by a formula.
+type RankedPlayer struct {
+	Rank       int                   `json:"rank"`
+	ID         string                `json:"id"`
+	Score      float64               `json:"score"`
+	Attributes model.PlayerAttribute `json:"attributes"`
+}
+
+// FormulaLeaderboard is a leaderboard re-ranked by a formula.
+type FormulaLeaderboard struct {
+	Formula RankingFormula `json:"formula"`
+	Players []RankedPlayer `json:"players"`
+}
+
+// RegisterRankingFormula adds a ranking formula that can then be queried with RankByFormula.
+func (ls *LeaderboardService) RegisterRankingFormula(name string, weights map[string]float64) error {
+	if name == "" || len(weights) == 0 {
+		return fmt.Errorf("svc: RegisterRankingFormula - formula %q needs a name and at least one weight", name)
+	}
+
+	var unknown []string
+	for stat := range weights {
+		if _, ok := statValues[stat]; !ok {
+			unknown = append(unknown, stat)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("svc: RegisterRankingFormula - formula %q uses unknown stats: %s", name, strings.Join(unknown, ", "))
+	}
+
+	ls.formulasMu.Lock()
+	defer ls.formulasMu.Unlock()
+	ls.formulas[name] = RankingFormula{Name: name, Weights: weights}
+
+	return nil
+}
+
+// RankingFormulas lists the registered ranking formulas ordered by name.
+func (ls *LeaderboardService) RankingFormulas() []RankingFormula {
+	ls.formulasMu.RLock()
+	defer ls.formulasMu.RUnlock()
+
+	formulas := make([]RankingFormula, 0, len(ls.formulas))
+	for _, formula := range ls.formulas {
+		formulas = append(formulas, formula)
+	}
+	sort.Slice(formulas, func(i, j int) bool { return formulas[i].Name < formulas[j].Name })
+
+	return formulas
+}
+
+// RankByFormula ranks the players of a leaderboard by the named formula, highest score first.
+// The boolean is false if no formula with that name is registered.
+func (ls *LeaderboardService) RankByFormula(leaderboard *model.LeaderboardResponse, name string) (*FormulaLeaderboard, bool) {
+	ls.formulasMu.RLock()
+	formula, ok := ls.formulas[name]
+	ls.formulasMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	players := make([]RankedPlayer, 0, len(leaderboard.Included))
+	for _, player := range leaderboard.Included {
+		attributes := withDerived(player.Attributes)
+		players = append(players, RankedPlayer{
+			ID:         player.ID,
+			Score:      formula.Score(attributes),
+			Attributes: attributes,
+		})
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		if players[i].Score == players[j].Score {
+			return players[i].Attributes.Rank < players[j].Attributes.Rank
+		}
+		return players[i].Score > players[j].Score
+	})
+	for i := range players {
+		players[i].Rank = i + 1
+	}
+
+	return &FormulaLeaderboard{Formula: formula, Players: players}, true
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+)
+
+// approxEqual reports whether two stats agree to within rounding.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		successes float64
+		trials    float64
+		wantLower float64
+		wantUpper float64
+	}{
+		{name: "typical win rate", successes: 5, trials: 50, wantLower: 0.04347512399157111, wantUpper: 0.2136049033478839},
+		{name: "small sample is wide", successes: 2, trials: 20, wantLower: 0.027865893984153206, wantUpper: 0.30103820641179335},
+		{name: "no wins is clamped at 0", successes: 0, trials: 10, wantLower: 0, wantUpper: 0.2775401687666166},
+		{name: "every game won is clamped at 1", successes: 10, trials: 10, wantLower: 0.7224598312333834, wantUpper: 1},
+		{name: "half is symmetric", successes: 50, trials: 100, wantLower: 0.40382982859014716, wantUpper: 0.5961701714098528},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lower, upper := wilsonInterval(tt.successes, tt.trials)
+			if !approxEqual(lower, tt.wantLower) || !approxEqual(upper, tt.wantUpper) {
+				t.Errorf("wilsonInterval(%v, %v) = [%v, %v], want [%v, %v]", tt.successes, tt.trials, lower, upper, tt.wantLower, tt.wantUpper)
+			}
+			if rate := tt.successes / tt.trials; lower > rate || upper < rate {
+				t.Errorf("interval [%v, %v] does not contain the observed rate %v", lower, upper, rate)
+			}
+		})
+	}
+}
+
+func TestComputeDerivedStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats model.PlayerStats
+		want  model.DerivedStats
+	}{
+		{
+			name:  "typical player",
+			stats: model.PlayerStats{Games: 20, Wins: 2, Kills: 40, AverageDamage: 250, AverageRank: 10},
+			want: model.DerivedStats{
+				KillsPerGame:  2,
+				DamagePerGame: 250,
+				Top10Rate:     0.6513215599,
+				WinRateLower:  0.027865893984153206,
+				WinRateUpper:  0.30103820641179335,
+				Consistency:   0.32566077995,
+			},
+		},
+		{
+			name:  "always first is always top 10, shrunk by the prior games",
+			stats: model.PlayerStats{Games: 100, Wins: 100, Kills: 500, AverageRank: 1},
+			want: model.DerivedStats{
+				KillsPerGame: 5,
+				Top10Rate:    1,
+				WinRateLower: 0.9630051925239981,
+				WinRateUpper: 1,
+				Consistency:  100.0 / 120.0,
+			},
+		},
+		{
+			name:  "no average rank has no top-10 rate",
+			stats: model.PlayerStats{Games: 10, Kills: 10},
+			want: model.DerivedStats{
+				KillsPerGame: 1,
+				WinRateUpper: 0.2775401687666166,
+			},
+		},
+		{
+			name:  "no games only repeats the damage",
+			stats: model.PlayerStats{AverageDamage: 180, Kills: 3, AverageRank: 5},
+			want:  model.DerivedStats{DamagePerGame: 180},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeDerivedStats(tt.stats)
+			fields := []struct {
+				name      string
+				got, want float64
+			}{
+				{"killsPerGame", got.KillsPerGame, tt.want.KillsPerGame},
+				{"damagePerGame", got.DamagePerGame, tt.want.DamagePerGame},
+				{"top10Rate", got.Top10Rate, tt.want.Top10Rate},
+				{"winRateLower", got.WinRateLower, tt.want.WinRateLower},
+				{"winRateUpper", got.WinRateUpper, tt.want.WinRateUpper},
+				{"consistency", got.Consistency, tt.want.Consistency},
+			}
+			for _, field := range fields {
+				if !approxEqual(field.got, field.want) {
+					t.Errorf("%s = %v, want %v", field.name, field.got, field.want)
+				}
+			}
+		})
+	}
+}
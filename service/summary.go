@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+)
+
+// summaryPercentiles are the rank point percentiles reported in a summary.
+var summaryPercentiles = map[string]float64{"p50": 50, "p75": 75, "p90": 90, "p95": 95, "p99": 99}
+
+// summaryThresholds are the leaderboard positions whose rank points cut-off is reported in a summary.
+var summaryThresholds = map[string]int{"top10": 10, "top100": 100, "top500": 500}
+
+// SummarizeLeaderboard computes the aggregate statistics of a leaderboard.
+func SummarizeLeaderboard(gameMode, seasonID string, leaderboard *model.LeaderboardResponse) *model.LeaderboardSummary {
+	summary := &model.LeaderboardSummary{
+		GameMode:              gameMode,
+		SeasonID:              seasonID,
+		GeneratedAt:           time.Now().UTC(),
+		Players:               len(leaderboard.Included),
+		Tiers:                 []model.TierCount{},
+		RankPointsPercentiles: make(map[string]float64),
+		RankPointsThresholds:  make(map[string]float64),
+	}
+	if summary.Players == 0 {
+		return summary
+	}
+
+	tierCounts := make(map[model.TierCount]int)
+	rankPoints := make([]float64, 0, summary.Players)
+	kda := make([]float64, 0, summary.Players)
+	damage := make([]float64, 0, summary.Players)
+	winRatio := make([]float64, 0, summary.Players)
+
+	for _, player := range leaderboard.Included {
+		stats := player.Attributes.Stats
+		tierCounts[model.TierCount{Tier: stats.Tier, SubTier: stats.SubTier}]++
+		rankPoints = append(rankPoints, stats.RankPoints)
+		kda = append(kda, stats.Kda)
+		damage = append(damage, stats.AverageDamage)
+		winRatio = append(winRatio, stats.WinRatio)
+	}
+
+	for tier, count := range tierCounts {
+		tier.Count = count
+		summary.Tiers = append(summary.Tiers, tier)
+	}
+	sort.Slice(summary.Tiers, func(i, j int) bool {
+		if summary.Tiers[i].Tier == summary.Tiers[j].Tier {
+			return summary.Tiers[i].SubTier < summary.Tiers[j].SubTier
+		}
+		return summary.Tiers[i].Tier < summary.Tiers[j].Tier
+	})
+
+	sort.Float64s(rankPoints)
+	for name, p := range summaryPercentiles {
+		summary.RankPointsPercentiles[name] = percentile(rankPoints, p)
+	}
+
+	// The cut-off for the top N is the lowest rank points among the N best-ranked players,
+	// reported only when the leaderboard is that long.
+	for name, position := range summaryThresholds {
+		if position <= len(rankPoints) {
+			summary.RankPointsThresholds[name] = rankPoints[len(rankPoints)-position]
+		}
+	}
+
+	summary.Kda = summarizeStat(kda)
+	summary.AverageDamage = summarizeStat(damage)
+	summary.WinRatio = summarizeStat(winRatio)
+
+	return summary
+}
+
+// percentile returns the p-th percentile of sorted values, interpolating linearly between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	position := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// summarizeStat computes the mean and median of the values, sorting them in place.
+func summarizeStat(values []float64) model.StatSummary {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	sort.Float64s(values)
+	return model.StatSummary{
+		Mean:   sum / float64(len(values)),
+		Median: percentile(values, 50),
+	}
+}
+
+// GetLeaderboardSummary retrieves the summary of a game mode's leaderboard, computing it from the leaderboard
+// when the cached summary has expired.
+func (ls *LeaderboardService) GetLeaderboardSummary(ctx context.Context, gameMode string) (*model.LeaderboardSummary, error) {
+	if err := ls.checkGameMode(gameMode); err != nil {
+		return nil, err
+	}
+
+	logger := ls.log(ctx).WithField("gameMode", gameMode)
+
+	summary, err := ls.redisClient.GetLeaderboardSummary(ctx, gameMode)
+	if err == nil {
+		ls.logSampler.Info(logger, "svc: GetLeaderboardSummary - Retrieved leaderboard summary from Redis")
+		return summary, nil
+	} else if err != store.ErrCacheMiss {
+		wrappedErr := fmt.Errorf("svc: GetLeaderboardSummary - failed to retrieve leaderboard summary from Redis: %w", err)
+		logger.WithError(wrappedErr).Error("svc: GetLeaderboardSummary - Failed to retrieve leaderboard summary from Redis")
+		return nil, wrappedErr
+	}
+
+	logger.Info("svc: GetLeaderboardSummary - Leaderboard summary cache miss in Redis, computing from leaderboard")
+
+	leaderboard, err := ls.GetLeaderboard(ctx, gameMode)
+	if err != nil {
+		return nil, fmt.Errorf("svc: GetLeaderboardSummary - failed to get leaderboard: %w", err)
+	}
+
+	seasonID := leaderboard.Data.Attributes.SeasonId
+	if season, err := ls.GetCurrentSeason(ctx); err == nil {
+		seasonID = season.ID
+	}
+
+	// Computed summaries are not cached so the history only records one point per refresh.
+	return SummarizeLeaderboard(gameMode, seasonID, leaderboard), nil
+}
+
+// GetSummaryHistory retrieves how the summary of a game mode's leaderboard moved during the current season.
+func (ls *LeaderboardService) GetSummaryHistory(ctx context.Context, gameMode string, since, until time.Time) ([]model.SummaryPoint, error) {
+	if err := ls.checkGameMode(gameMode); err != nil {
+		return nil, err
+	}
+
+	season, err := ls.GetCurrentSeason(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("svc: GetSummaryHistory - failed to get current season: %w", err)
+	}
+
+	points, err := ls.redisClient.GetSummaryHistory(ctx, gameMode, season.ID, since, until)
+	if err != nil {
+		wrappedErr := fmt.Errorf("svc: GetSummaryHistory - failed to retrieve summary history from Redis: %w", err)
+		ls.log(ctx).WithError(wrappedErr).WithField("gameMode", gameMode).Error("svc: GetSummaryHistory - Failed to retrieve summary history from Redis")
+		return nil, wrappedErr
+	}
+
+	return points, nil
+}
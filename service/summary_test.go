@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{name: "single value", sorted: []float64{42}, p: 90, want: 42},
+		{name: "minimum", sorted: []float64{10, 20, 30, 40}, p: 0, want: 10},
+		{name: "maximum", sorted: []float64{10, 20, 30, 40}, p: 100, want: 40},
+		{name: "median of an odd count", sorted: []float64{10, 20, 30}, p: 50, want: 20},
+		{name: "median of an even count interpolates", sorted: []float64{10, 20, 30, 40}, p: 50, want: 25},
+		{name: "on a rank", sorted: []float64{0, 10, 20, 30, 40}, p: 75, want: 30},
+		{name: "between ranks", sorted: []float64{10, 20, 30, 40}, p: 90, want: 37},
+		{name: "equal values", sorted: []float64{5, 5, 5}, p: 99, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); !approxEqual(got, tt.want) {
+				t.Errorf("percentile(%v, %v) = %v, want %v", tt.sorted, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+// summaryPlayer is the part of a player's stats a summary test sets; the rank points follow testutil.Leaderboard.
+type summaryPlayer struct {
+	tier    string
+	subTier string
+	kda     float64
+}
+
+func TestSummarizeLeaderboard(t *testing.T) {
+	masters := func(n int) []summaryPlayer {
+		players := make([]summaryPlayer, n)
+		for i := range players {
+			players[i] = summaryPlayer{tier: "Master", subTier: "1", kda: 2}
+		}
+		return players
+	}
+
+	tests := []struct {
+		name            string
+		players         []summaryPlayer
+		wantTiers       []model.TierCount
+		wantPercentiles map[string]float64
+		wantThresholds  map[string]float64
+		wantKda         model.StatSummary
+	}{
+		{
+			name:            "empty leaderboard",
+			wantTiers:       []model.TierCount{},
+			wantPercentiles: map[string]float64{},
+			wantThresholds:  map[string]float64{},
+		},
+		{
+			name:            "single player",
+			players:         []summaryPlayer{{tier: "Master", subTier: "1", kda: 3}},
+			wantTiers:       []model.TierCount{{Tier: "Master", SubTier: "1", Count: 1}},
+			wantPercentiles: map[string]float64{"p50": 5000, "p75": 5000, "p90": 5000, "p95": 5000, "p99": 5000},
+			wantThresholds:  map[string]float64{},
+			wantKda:         model.StatSummary{Mean: 3, Median: 3},
+		},
+		{
+			name: "tiers are counted and sorted",
+			players: []summaryPlayer{
+				{tier: "Master", subTier: "1", kda: 4},
+				{tier: "Diamond", subTier: "2", kda: 1},
+				{tier: "Diamond", subTier: "1", kda: 2},
+				{tier: "Diamond", subTier: "1", kda: 5},
+			},
+			wantTiers: []model.TierCount{
+				{Tier: "Diamond", SubTier: "1", Count: 2},
+				{Tier: "Diamond", SubTier: "2", Count: 1},
+				{Tier: "Master", SubTier: "1", Count: 1},
+			},
+			// Rank points 4970, 4980, 4990 and 5000.
+			wantPercentiles: map[string]float64{"p50": 4985, "p75": 4992.5, "p90": 4997, "p95": 4998.5, "p99": 4999.7},
+			wantThresholds:  map[string]float64{},
+			wantKda:         model.StatSummary{Mean: 3, Median: 3},
+		},
+		{
+			name:            "threshold reported once the leaderboard is long enough",
+			players:         masters(10),
+			wantTiers:       []model.TierCount{{Tier: "Master", SubTier: "1", Count: 10}},
+			wantPercentiles: map[string]float64{"p50": 4955, "p75": 4977.5, "p90": 4991, "p95": 4995.5, "p99": 4999.1},
+			wantThresholds:  map[string]float64{"top10": 4910},
+			wantKda:         model.StatSummary{Mean: 2, Median: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make([]string, len(tt.players))
+			for i := range ids {
+				ids[i] = fmt.Sprint(i)
+			}
+			leaderboard := testutil.Leaderboard("squad-fpp", ids...)
+			for i, player := range tt.players {
+				stats := &leaderboard.Included[i].Attributes.Stats
+				stats.Tier, stats.SubTier, stats.Kda = player.tier, player.subTier, player.kda
+			}
+
+			summary := SummarizeLeaderboard("squad-fpp", testutil.Season, leaderboard)
+			if summary.Players != len(tt.players) || summary.GameMode != "squad-fpp" || summary.SeasonID != testutil.Season {
+				t.Errorf("got %d players of %s in season %s, want %d", summary.Players, summary.GameMode, summary.SeasonID, len(tt.players))
+			}
+			if !reflect.DeepEqual(summary.Tiers, tt.wantTiers) {
+				t.Errorf("got tiers %v, want %v", summary.Tiers, tt.wantTiers)
+			}
+			for _, stat := range []struct {
+				name      string
+				got, want map[string]float64
+			}{
+				{"rank points percentiles", summary.RankPointsPercentiles, tt.wantPercentiles},
+				{"rank points thresholds", summary.RankPointsThresholds, tt.wantThresholds},
+			} {
+				if len(stat.got) != len(stat.want) {
+					t.Errorf("got %s %v, want %v", stat.name, stat.got, stat.want)
+					continue
+				}
+				for key, want := range stat.want {
+					if got, ok := stat.got[key]; !ok || !approxEqual(got, want) {
+						t.Errorf("got %s %v, want %v", stat.name, stat.got, stat.want)
+						break
+					}
+				}
+			}
+			if !approxEqual(summary.Kda.Mean, tt.wantKda.Mean) || !approxEqual(summary.Kda.Median, tt.wantKda.Median) {
+				t.Errorf("got kda %+v, want %+v", summary.Kda, tt.wantKda)
+			}
+		})
+	}
+}
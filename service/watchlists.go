@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/google/uuid"
+)
+
+// MaxWatchlistPlayers bounds the number of players on a single watchlist.
+const MaxWatchlistPlayers = 200
+
+// ErrInvalidWatchlist is returned when a watchlist fails validation.
+var ErrInvalidWatchlist = errors.New("invalid watchlist")
+
+// WatchlistInput holds the fields of a watchlist that clients can set.
+type WatchlistInput struct {
+	Name          string   `json:"name"`
+	PlayerIDs     []string `json:"playerIds"`
+	MoveThreshold int      `json:"moveThreshold"`
+}
+
+// normalize trims and de-duplicates the player IDs, keeping their order, and validates the input.
+func (in *WatchlistInput) normalize() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return fmt.Errorf("svc: WatchlistInput - name is required: %w", ErrInvalidWatchlist)
+	}
+	if in.MoveThreshold < 0 {
+		return fmt.Errorf("svc: WatchlistInput - moveThreshold must not be negative: %w", ErrInvalidWatchlist)
+	}
+
+	seen := make(map[string]bool, len(in.PlayerIDs))
+	playerIDs := make([]string, 0, len(in.PlayerIDs))
+	for _, playerID := range in.PlayerIDs {
+		playerID = strings.TrimSpace(playerID)
+		if playerID == "" || seen[playerID] {
+			continue
+		}
+		seen[playerID] = true
+		playerIDs = append(playerIDs, playerID)
+	}
+	if len(playerIDs) == 0 || len(playerIDs) > MaxWatchlistPlayers {
+		return fmt.Errorf("svc: WatchlistInput - between 1 and %d player IDs are required: %w", MaxWatchlistPlayers, ErrInvalidWatchlist)
+	}
+	in.PlayerIDs = playerIDs
+
+	return nil
+}
+
+// CreateWatchlist stores a new watchlist.
+func (ls *LeaderboardService) CreateWatchlist(ctx context.Context, input WatchlistInput) (*model.Watchlist, error) {
+	if err := input.normalize(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	watchlist := &model.Watchlist{
+		ID:            uuid.NewString(),
+		Name:          input.Name,
+		PlayerIDs:     input.PlayerIDs,
+		MoveThreshold: input.MoveThreshold,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := ls.redisClient.SaveWatchlist(ctx, watchlist); err != nil {
+		return nil, fmt.Errorf("svc: CreateWatchlist - failed to save watchlist in Redis: %w", err)
+	}
+
+	return watchlist, nil
+}
+
+// UpdateWatchlist replaces the name, players and move threshold of a watchlist.
+func (ls *LeaderboardService) UpdateWatchlist(ctx context.Context, watchlistID string, input WatchlistInput) (*model.Watchlist, error) {
+	if err := input.normalize(); err != nil {
+		return nil, err
+	}
+
+	watchlist, err := ls.redisClient.GetWatchlist(ctx, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist.Name = input.Name
+	watchlist.PlayerIDs = input.PlayerIDs
+	watchlist.MoveThreshold = input.MoveThreshold
+	watchlist.UpdatedAt = time.Now().UTC()
+
+	if err := ls.redisClient.SaveWatchlist(ctx, watchlist); err != nil {
+		return nil, fmt.Errorf("svc: UpdateWatchlist - failed to save watchlist in Redis: %w", err)
+	}
+
+	return watchlist, nil
+}
+
+// GetWatchlist retrieves a watchlist, returning store.ErrCacheMiss if it does not exist.
+func (ls *LeaderboardService) GetWatchlist(ctx context.Context, watchlistID string) (*model.Watchlist, error) {
+	return ls.redisClient.GetWatchlist(ctx, watchlistID)
+}
+
+// ListWatchlists retrieves every watchlist.
+func (ls *LeaderboardService) ListWatchlists(ctx context.Context) ([]*model.Watchlist, error) {
+	return ls.redisClient.ListWatchlists(ctx)
+}
+
+// DeleteWatchlist removes a watchlist, returning store.ErrCacheMiss if it does not exist.
+func (ls *LeaderboardService) DeleteWatchlist(ctx context.Context, watchlistID string) error {
+	return ls.redisClient.DeleteWatchlist(ctx, watchlistID)
+}
+
+// GetWatchlistLeaderboard retrieves the standing of a watchlist's players on the leaderboard of a game mode,
+// with their rank change since the previous refresh and, when since is given, since that time. Players on the
+// leaderboard come first by rank, followed by the others in watchlist order.
+func (ls *LeaderboardService) GetWatchlistLeaderboard(ctx context.Context, watchlistID, gameMode string, since *time.Time) (*model.WatchlistLeaderboard, error) {
+	watchlist, err := ls.redisClient.GetWatchlist(ctx, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard, err := ls.GetLeaderboard(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ranks restart with every season, so deltas only compare against the current season's history.
+	season, err := ls.GetCurrentSeason(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("svc: GetWatchlistLeaderboard - failed to get current season: %w", err)
+	}
+
+	current := make(map[string]model.PlayerAttribute, len(leaderboard.Included))
+	for _, player := range leaderboard.Included {
+		if watchlist.Watches(player.ID) {
+			current[player.ID] = player.Attributes
+		}
+	}
+
+	// The newest point is the current refresh, the one before it the previous refresh. The history of every
+	// watched player on the leaderboard is read in a single round trip.
+	now := time.Now()
+	var queries []store.RankPointsQuery
+	for _, playerID := range watchlist.PlayerIDs {
+		if _, ok := current[playerID]; !ok {
+			continue
+		}
+		queries = append(queries, store.RankPointsQuery{PlayerID: playerID, Before: now, Count: 2})
+		if since != nil {
+			queries = append(queries, store.RankPointsQuery{PlayerID: playerID, Before: *since, Count: 1})
+		}
+	}
+	history, err := ls.redisClient.GetRecentRankPoints(ctx, gameMode, season.ID, queries)
+	if err != nil {
+		return nil, fmt.Errorf("svc: GetWatchlistLeaderboard - failed to retrieve rank history from Redis: %w", err)
+	}
+
+	players := make([]model.WatchedPlayer, 0, len(watchlist.PlayerIDs))
+	for _, playerID := range watchlist.PlayerIDs {
+		attributes, ok := current[playerID]
+		if !ok {
+			players = append(players, model.WatchedPlayer{ID: playerID})
+			continue
+		}
+
+		watched := model.WatchedPlayer{
+			ID:            playerID,
+			Name:          attributes.Name,
+			OnLeaderboard: true,
+			Rank:          attributes.Rank,
+			RankPoints:    attributes.Stats.RankPoints,
+			Tier:          attributes.Stats.Tier,
+			SubTier:       attributes.Stats.SubTier,
+		}
+
+		recent := history[0]
+		history = history[1:]
+		if len(recent) == 2 {
+			watched.DeltaLastRefresh = rankDelta(recent[1].Rank, attributes.Rank)
+		}
+
+		if since != nil {
+			earlier := history[0]
+			history = history[1:]
+			if len(earlier) == 1 {
+				watched.DeltaSince = rankDelta(earlier[0].Rank, attributes.Rank)
+			}
+		}
+
+		players = append(players, watched)
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		if players[i].OnLeaderboard != players[j].OnLeaderboard {
+			return players[i].OnLeaderboard
+		}
+		return players[i].OnLeaderboard && players[i].Rank < players[j].Rank
+	})
+
+	return &model.WatchlistLeaderboard{
+		Watchlist: watchlist,
+		GameMode:  gameMode,
+		Since:     since,
+		Players:   players,
+	}, nil
+}
+
+// rankDelta returns the number of places a player climbed from oldRank to newRank.
+func rankDelta(oldRank, newRank int) *int {
+	delta := oldRank - newRank
+	return &delta
+}
+
+// trackWatchlists records the ranks of every watched player in the season after a refresh and emits
+// EventWatchlistPlayerMoved for players who moved more than their watchlist's threshold since the previous
+// leaderboard. Refreshes that found the leaderboard unchanged pass no previous leaderboard: they still record a
+// point, so deltas are measured against the last refresh, but nobody moved. Watchlists are secondary data, so
+// failures are logged rather than failing the refresh.
+func (ls *LeaderboardService) trackWatchlists(ctx context.Context, gameMode, seasonID string, previous, current *model.LeaderboardResponse) {
+	logger := ls.log(ctx).WithField("gameMode", gameMode)
+
+	watchlists, err := ls.redisClient.ListWatchlists(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("svc: trackWatchlists - Failed to list watchlists")
+		return
+	}
+	if len(watchlists) == 0 {
+		return
+	}
+
+	watched := make(map[string]bool)
+	for _, watchlist := range watchlists {
+		for _, playerID := range watchlist.PlayerIDs {
+			watched[playerID] = true
+		}
+	}
+
+	now := time.Now().UTC()
+	points := make(map[string]model.RankPoint)
+	for _, player := range current.Included {
+		if watched[player.ID] {
+			points[player.ID] = model.RankPoint{Timestamp: now, Rank: player.Attributes.Rank, RankPoints: player.Attributes.Stats.RankPoints}
+		}
+	}
+	if err := ls.redisClient.AddRankPoints(ctx, gameMode, seasonID, points); err != nil {
+		logger.WithError(err).Warn("svc: trackWatchlists - Failed to record rank history")
+	}
+
+	if previous == nil {
+		return
+	}
+	before := make(map[string]int, len(previous.Included))
+	for _, player := range previous.Included {
+		if watched[player.ID] {
+			before[player.ID] = player.Attributes.Rank
+		}
+	}
+
+	for _, watchlist := range watchlists {
+		if watchlist.MoveThreshold == 0 {
+			continue
+		}
+		for _, player := range current.Included {
+			oldRank, existed := before[player.ID]
+			if !existed || !watchlist.Watches(player.ID) {
+				continue
+			}
+			moved := oldRank - player.Attributes.Rank
+			if moved > watchlist.MoveThreshold || -moved > watchlist.MoveThreshold {
+				ls.emit(ctx, model.EventWatchlistPlayerMoved, gameMode, model.WatchlistMoveEventData{
+					WatchlistID:   watchlist.ID,
+					WatchlistName: watchlist.Name,
+					Player: model.PlayerEventData{
+						ID:      player.ID,
+						Name:    player.Attributes.Name,
+						Rank:    player.Attributes.Rank,
+						OldRank: oldRank,
+					},
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/gbasileGP/pubg-leaderboard/internal/testutil"
+)
+
+func TestUnchangedRefreshRecordsRankHistory(t *testing.T) {
+	ctx := context.Background()
+	pubg := testutil.NewFakePUBG(t, testutil.Leaderboard("squad-fpp", "a", "b"))
+	ls, _ := newTestService(t, pubg)
+
+	watchlist, err := ls.CreateWatchlist(ctx, WatchlistInput{Name: "friends", PlayerIDs: []string{"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ls.refreshGameMode(ctx, testutil.Season, "squad-fpp"); err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	stored, err := ls.redisClient.GetLeaderboard(ctx, "squad-fpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The PUBG API answers the second refresh 304 Not Modified, so the leaderboard is only extended.
+	if err := ls.refreshGameMode(ctx, testutil.Season, "squad-fpp"); err != nil {
+		t.Fatalf("second refresh: %v", err)
+	}
+	touched, err := ls.redisClient.GetLeaderboard(ctx, "squad-fpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !touched.FetchedAt.Equal(*stored.FetchedAt) {
+		t.Fatal("the unchanged leaderboard was rewritten, want it extended")
+	}
+
+	result, err := ls.GetWatchlistLeaderboard(ctx, watchlist.ID, "squad-fpp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delta := result.Players[0].DeltaLastRefresh; delta == nil || *delta != 0 {
+		t.Errorf("got delta since the last refresh %v, want 0 against the unchanged refresh", delta)
+	}
+
+	// The history belongs to the season it was recorded in.
+	query := []store.RankPointsQuery{{PlayerID: "b", Before: time.Now(), Count: 10}}
+	for _, tt := range []struct {
+		seasonID   string
+		wantPoints int
+	}{
+		{seasonID: testutil.Season, wantPoints: 2},
+		{seasonID: "division.bro.official.pc-2018-29", wantPoints: 0},
+	} {
+		history, err := ls.redisClient.GetRecentRankPoints(ctx, "squad-fpp", tt.seasonID, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(history[0]); got != tt.wantPoints {
+			t.Errorf("season %s: got %d rank points, want %d", tt.seasonID, got, tt.wantPoints)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gbasileGP/pubg-leaderboard/internal/config"
+	"github.com/gbasileGP/pubg-leaderboard/internal/lifecycle"
+	"github.com/gbasileGP/pubg-leaderboard/internal/logging"
+	"github.com/gbasileGP/pubg-leaderboard/internal/model"
+	"github.com/gbasileGP/pubg-leaderboard/internal/store"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidWebhook is returned when a webhook subscription fails validation.
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// ErrForbiddenAddress is returned when a webhook delivery would connect to an address deliveries may not reach.
+var ErrForbiddenAddress = errors.New("webhook address not allowed")
+
+// forbiddenPrefixes are the ranges that are neither private nor link-local but are still not reachable on the
+// public internet: "this network", shared address space, IETF protocol assignments, benchmarking and reserved.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// publicAddress reports whether ip is a public unicast address, excluding loopback, link-local, private and
+// reserved ranges. IPv4 addresses mapped to IPv6 are checked as IPv4.
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicOnlyControl is the net.Dialer Control of webhook deliveries. It runs on the resolved address of every
+// connection, so a webhook host that resolves to an internal address is refused, even if its DNS answer changed
+// since the webhook was registered.
+func publicOnlyControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("svc: publicOnlyControl - %s: %w", address, ErrForbiddenAddress)
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !publicAddress(ip) {
+		return fmt.Errorf("svc: publicOnlyControl - %s: %w", address, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// newDeliveryClient returns the HTTP client of webhook deliveries. Unless allowPrivate is set, it only connects
+// to public addresses and ignores proxies, which would otherwise be dialed in place of the webhook host.
+func newDeliveryClient(timeout time.Duration, allowPrivate bool) *resty.Client {
+	httpClient := resty.New().SetTimeout(timeout)
+	if allowPrivate {
+		return httpClient
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   publicOnlyControl,
+	}).DialContext
+	return httpClient.SetTransport(transport)
+}
+
+// webhooksCacheTTL is how long the subscriptions are kept in memory to route events. Subscriptions changed through
+// this replica apply at once, those changed through another replica once the cache expires.
+const webhooksCacheTTL = 30 * time.Second
+
+// WebhookService manages webhook subscriptions and delivers events to them.
+type WebhookService struct {
+	redisClient *store.RedisClient
+	httpClient  *resty.Client
+	logger      *logrus.Logger
+	maxAttempts int
+	backoff     time.Duration
+
+	allowPrivate bool // Whether webhooks may target loopback, link-local and private addresses
+
+	deliveries *lifecycle.Group // Deliveries in flight, cancelled by Stop
+
+	webhooksMu       sync.Mutex
+	webhooks         []*model.WebhookSubscription // Subscriptions events are routed to, nil until loaded
+	webhooksLoadedAt time.Time
+}
+
+// NewWebhookService creates a new service for webhook subscriptions and deliveries.
+func NewWebhookService(cfg *config.Config, redisClient *store.RedisClient, logger *logrus.Logger) *WebhookService {
+	return &WebhookService{
+		redisClient: redisClient,
+		httpClient:  newDeliveryClient(cfg.WebhookTimeout, cfg.WebhookAllowPrivate),
+		logger:      logger,
+		maxAttempts: cfg.WebhookMaxAttempts,
+		backoff:     cfg.WebhookBackoff,
+		deliveries:  lifecycle.NewGroup(),
+
+		allowPrivate: cfg.WebhookAllowPrivate,
+	}
+}
+
+// log returns the logger of the request being served in ctx, tagged with its request ID, or the service logger
+// outside of requests.
+func (ws *WebhookService) log(ctx context.Context) *logrus.Entry {
+	return logging.FromContext(ctx, ws.logger)
+}
+
+// Start does nothing, deliveries are started by events. It lets the service be managed with the others.
+func (ws *WebhookService) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop cancels the deliveries in flight, including their pending retries, and waits for them to be recorded.
+func (ws *WebhookService) Stop(ctx context.Context) error {
+	return ws.deliveries.Stop(ctx)
+}
+
+// WebhookInput holds the fields of a webhook subscription that clients can set.
+type WebhookInput struct {
+	URL    string            `json:"url"`
+	Secret string            `json:"secret"`
+	Events []model.EventType `json:"events"`
+	Active *bool             `json:"active"`
+}
+
+// validate checks the URL and event types of the input. Unless allowPrivate is set, URLs naming localhost or an
+// address that is not public are rejected up front; hosts resolving to one are refused when delivering.
+func (in WebhookInput) validate(allowPrivate bool) error {
+	parsed, err := url.Parse(in.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("svc: WebhookInput - url must be an absolute http or https URL: %w", ErrInvalidWebhook)
+	}
+	if !allowPrivate {
+		host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+		ip, err := netip.ParseAddr(host)
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") || (err == nil && !publicAddress(ip)) {
+			return fmt.Errorf("svc: WebhookInput - url must not target a loopback, link-local or private address: %w", ErrInvalidWebhook)
+		}
+	}
+
+	for _, eventType := range in.Events {
+		known := false
+		for _, candidate := range model.EventTypes {
+			if candidate == eventType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("svc: WebhookInput - unknown event type %q: %w", eventType, ErrInvalidWebhook)
+		}
+	}
+
+	return nil
+}
+
+// CreateWebhook registers a new webhook subscription. A secret is generated when none is given.
+func (ws *WebhookService) CreateWebhook(ctx context.Context, input WebhookInput) (*model.WebhookSubscription, error) {
+	if err := input.validate(ws.allowPrivate); err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		generated := make([]byte, 32)
+		if _, err := rand.Read(generated); err != nil {
+			return nil, fmt.Errorf("svc: CreateWebhook - failed to generate secret: %w", err)
+		}
+		secret = hex.EncodeToString(generated)
+	}
+
+	now := time.Now().UTC()
+	webhook := &model.WebhookSubscription{
+		ID:        uuid.NewString(),
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    input.Events,
+		Active:    input.Active == nil || *input.Active,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := ws.redisClient.SaveWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("svc: CreateWebhook - failed to save webhook in Redis: %w", err)
+	}
+	ws.invalidateWebhooks()
+
+	ws.log(ctx).WithField("webhookID", webhook.ID).Info("svc: CreateWebhook - Registered webhook")
+	return webhook, nil
+}
+
+// UpdateWebhook replaces the URL and events of a subscription, and its secret and active flag when given.
+func (ws *WebhookService) UpdateWebhook(ctx context.Context, webhookID string, input WebhookInput) (*model.WebhookSubscription, error) {
+	if err := input.validate(ws.allowPrivate); err != nil {
+		return nil, err
+	}
+
+	webhook, err := ws.redisClient.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = input.URL
+	webhook.Events = input.Events
+	if input.Secret != "" {
+		webhook.Secret = input.Secret
+	}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+	webhook.UpdatedAt = time.Now().UTC()
+
+	if err := ws.redisClient.SaveWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("svc: UpdateWebhook - failed to save webhook in Redis: %w", err)
+	}
+	ws.invalidateWebhooks()
+
+	return webhook, nil
+}
+
+// GetWebhook retrieves a subscription, returning store.ErrCacheMiss if it does not exist.
+func (ws *WebhookService) GetWebhook(ctx context.Context, webhookID string) (*model.WebhookSubscription, error) {
+	return ws.redisClient.GetWebhook(ctx, webhookID)
+}
+
+// ListWebhooks retrieves every subscription.
+func (ws *WebhookService) ListWebhooks(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	return ws.redisClient.ListWebhooks(ctx)
+}
+
+// DeleteWebhook removes a subscription, returning store.ErrCacheMiss if it does not exist.
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if err := ws.redisClient.DeleteWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+	ws.invalidateWebhooks()
+	return nil
+}
+
+// cachedWebhooks returns the subscriptions events are routed to, listing them from Redis once the cached ones
+// have expired so a burst of events costs a single round trip.
+func (ws *WebhookService) cachedWebhooks(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	ws.webhooksMu.Lock()
+	defer ws.webhooksMu.Unlock()
+
+	if ws.webhooks != nil && time.Since(ws.webhooksLoadedAt) < webhooksCacheTTL {
+		return ws.webhooks, nil
+	}
+
+	webhooks, err := ws.redisClient.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws.webhooks = webhooks
+	ws.webhooksLoadedAt = time.Now()
+	return webhooks, nil
+}
+
+// invalidateWebhooks drops the cached subscriptions after one of them changed.
+func (ws *WebhookService) invalidateWebhooks() {
+	ws.webhooksMu.Lock()
+	defer ws.webhooksMu.Unlock()
+	ws.webhooks = nil
+}
+
+// ListDeliveries retrieves the recent deliveries of a subscription, newest first.
+func (ws *WebhookService) ListDeliveries(ctx context.Context, webhookID string) ([]model.WebhookDelivery, error) {
+	if _, err := ws.redisClient.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return ws.redisClient.ListWebhookDeliveries(ctx, webhookID)
+}
+
+// TestWebhook delivers a webhook.test event to the subscription once, without retries, and returns the outcome.
+func (ws *WebhookService) TestWebhook(ctx context.Context, webhookID string) (*model.WebhookDelivery, error) {
+	webhook, err := ws.redisClient.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := model.Event{
+		ID:   uuid.NewString(),
+		Type: model.EventWebhookTest,
+		Time: time.Now().UTC(),
+		Data: map[string]string{"message": "Test delivery from the PUBG leaderboard service"},
+	}
+
+	return ws.deliver(ctx, webhook, event, 1), nil
+}
+
+// HandleEvent delivers an event to every active subscription that wants it. Deliveries run in the background
+// until the service stops, so slow receivers do not hold up the refresh that emitted the event. It is meant to be registered with
+// LeaderboardService.OnEvent.
+func (ws *WebhookService) HandleEvent(ctx context.Context, event model.Event) {
+	webhooks, err := ws.cachedWebhooks(ctx)
+	if err != nil {
+		ws.log(ctx).WithError(err).Error("svc: HandleEvent - Failed to list webhooks")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || !webhook.Wants(event.Type) {
+			continue
+		}
+		webhook := webhook
+		ws.deliveries.Go(func(ctx context.Context) {
+			ws.deliver(ctx, webhook, event, ws.maxAttempts)
+		})
+	}
+}
+
+// deliver posts the signed event to the subscription, retrying with exponential backoff, and records the outcome.
+func (ws *WebhookService) deliver(ctx context.Context, webhook *model.WebhookSubscription, event model.Event, maxAttempts int) *model.WebhookDelivery {
+	delivery := &model.WebhookDelivery{
+		ID:        uuid.NewString(),
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		StartedAt: time.Now().UTC(),
+	}
+	logger := ws.log(ctx).WithFields(logrus.Fields{
+		"webhookID": webhook.ID,
+		"eventType": event.Type,
+	})
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		delivery.Error = fmt.Sprintf("failed to marshal event: %v", err)
+	}
+
+	backoff := ws.backoff
+	for attempt := 1; body != nil && attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := sleepContext(ctx, backoff); err != nil {
+				delivery.Error = err.Error()
+				break
+			}
+			backoff *= 2
+		}
+		delivery.Attempts = attempt
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		resp, err := ws.httpClient.R().
+			SetContext(ctx).
+			SetHeader("Content-Type", "application/json").
+			SetHeader("X-Webhook-ID", webhook.ID).
+			SetHeader("X-Webhook-Event", string(event.Type)).
+			SetHeader("X-Webhook-Delivery", delivery.ID).
+			SetHeader("X-Webhook-Timestamp", timestamp).
+			SetHeader("X-Webhook-Signature", SignWebhookPayload(webhook.Secret, timestamp, body)).
+			SetBody(body).
+			Post(webhook.URL)
+		if err != nil {
+			delivery.Error = err.Error()
+			continue
+		}
+
+		delivery.StatusCode = resp.StatusCode()
+		if resp.IsSuccess() {
+			delivery.Success = true
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = fmt.Sprintf("receiver responded with %s", resp.Status())
+	}
+	delivery.FinishedAt = time.Now().UTC()
+
+	if delivery.Success {
+		logger.Info("svc: deliver - Delivered webhook event")
+	} else {
+		logger.WithField("attempts", delivery.Attempts).WithField("error", delivery.Error).Warn("svc: deliver - Giving up on webhook event")
+	}
+
+	// Record the outcome even if the caller's context has ended.
+	if err := ws.redisClient.AddWebhookDelivery(context.Background(), delivery); err != nil {
+		logger.WithError(err).Error("svc: deliver - Failed to record webhook delivery")
+	}
+
+	return delivery
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature header value for a payload: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret, prefixed with "sha256=".
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sleepContext waits for the duration, returning early with the context's error if it is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
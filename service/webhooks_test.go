@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+	}
+
+	for _, tt := range tests {
+		if got := publicAddress(netip.MustParseAddr(tt.address)); got != tt.want {
+			t.Errorf("publicAddress(%s) = %t, want %t", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookInputRejectsInternalURLs(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{url: "https://hooks.example.com/pubg"},
+		{url: "http://127.0.0.1:8080/hook", wantErr: true},
+		{url: "http://localhost/hook", wantErr: true},
+		{url: "http://[::1]/hook", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "http://10.0.0.5/hook", wantErr: true},
+		{url: "http://127.0.0.1:8080/hook", allowPrivate: true},
+	}
+
+	for _, tt := range tests {
+		err := WebhookInput{URL: tt.url}.validate(tt.allowPrivate)
+		if gotErr := errors.Is(err, ErrInvalidWebhook); gotErr != tt.wantErr {
+			t.Errorf("validate(%s, allowPrivate=%t) returned %v, want error %t", tt.url, tt.allowPrivate, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDeliveryClientRefusesPrivateAddresses(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer receiver.Close()
+
+	// The dialer checks the address connected to, so the refusal covers hosts resolving to a private address.
+	_, err := newDeliveryClient(time.Second, false).R().Post(receiver.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Post returned %v, want %v", err, ErrForbiddenAddress)
+	}
+	if requests.Load() != 0 {
+		t.Error("the receiver on a private address was reached")
+	}
+
+	if _, err := newDeliveryClient(time.Second, true).R().Post(receiver.URL); err != nil || requests.Load() != 1 {
+		t.Errorf("Post returned %v with private addresses allowed, want the receiver reached", err)
+	}
+}